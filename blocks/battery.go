@@ -3,8 +3,14 @@ package blocks
 import (
 	"context"
 	"fmt"
+	"math"
+	"os/exec"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/godbus/dbus/v5"
+	"github.com/kraftwerk28/gost/blocks/sysfs"
 	. "github.com/kraftwerk28/gost/core"
 	"github.com/kraftwerk28/gost/core/formatting"
 )
@@ -23,8 +29,40 @@ const (
 )
 
 const (
-	deviceTypeBattery  uint32 = 2
-	deviceTypeKeyboard uint32 = 6
+	deviceTypeUnknown     uint32 = 0
+	deviceTypeLinePower   uint32 = 1
+	deviceTypeBattery     uint32 = 2
+	deviceTypeMouse       uint32 = 5
+	deviceTypeKeyboard    uint32 = 6
+	deviceTypePhone       uint32 = 8
+	deviceTypeTablet      uint32 = 10
+	deviceTypeGamingInput uint32 = 12
+	deviceTypeHeadset     uint32 = 17
+	deviceTypeHeadphones  uint32 = 19
+)
+
+// Names used as keys of `device_icons`
+var upowerDeviceTypeNames = map[uint32]string{
+	deviceTypeMouse:       "mouse",
+	deviceTypeKeyboard:    "keyboard",
+	deviceTypePhone:       "phone",
+	deviceTypeTablet:      "tablet",
+	deviceTypeGamingInput: "gamepad",
+	deviceTypeHeadset:     "headset",
+	deviceTypeHeadphones:  "headphones",
+}
+
+const upowerDisplayDevice dbus.ObjectPath = upowerDbusBasePath + "/devices/DisplayDevice"
+
+const (
+	// Single device, either `upower_device` or the first laptop battery
+	batteryModeDevice = ""
+	// All system batteries combined by energy
+	batteryModeAggregate = "aggregate"
+	// A block per peripheral device (mouse, keyboard etc.)
+	batteryModeDevices = "devices"
+	// UPower's composite DisplayDevice
+	batteryModeDisplayDevice = "display_device"
 )
 
 // Display battery charge level. Requires UPower to work over DBus
@@ -40,31 +78,225 @@ const (
 // If empty, the program will try to detect battery device.
 type BatteryBlockConfig struct {
 	Format *ConfigFormat `yaml:"format"`
-	// Device name. See above.
+	// Device name. See above. For `sysfs` backend it's the power supply name,
+	// i.e. `BAT0`
 	UpowerDevice string            `yaml:"upower_device"`
 	StateIcons   map[string]string `yaml:"state_icons"`
 	LevelIcons   []string          `yaml:"level_icons"`
 	UrgentLevel  *int              `yaml:"urgent_level"`
+	// One of: `aggregate`, `devices`, `display_device`. Single device if empty
+	Mode string `yaml:"mode"`
+	// Format of a peripheral in `devices` mode
+	DeviceFormat *ConfigFormat `yaml:"device_format"`
+	// Icons for peripherals, keyed by type: `mouse`, `keyboard`, `headset`,
+	// `headphones`, `gamepad`, `phone`, `tablet`
+	DeviceIcons map[string]string `yaml:"device_icons"`
+	// Either `upower` (default) or `sysfs`, which reads
+	// /sys/class/power_supply directly
+	Backend string `yaml:"backend"`
+	// Root directory for `sysfs` backend
+	SysfsRoot string `yaml:"sysfs_root"`
+	// Polling interval for `sysfs` backend
+	Interval *ConfigInterval `yaml:"interval"`
+	// Also refresh `sysfs` backend on kernel `power_supply` uevents
+	Uevents bool `yaml:"uevents"`
+	// Desktop notification rules. Ignored in `devices` mode
+	Notifications []BatteryNotifyRule `yaml:"notifications"`
+}
+
+const (
+	batteryEventFullyCharged = "fully_charged"
+	batteryEventAcPlugged    = "ac_plugged"
+	batteryEventAcUnplugged  = "ac_unplugged"
+)
+
+// A notification is sent when the discharging battery drops to `level`, or on
+// event specified by `on`: `fully_charged`, `ac_plugged`, `ac_unplugged`
+type BatteryNotifyRule struct {
+	Level *int   `yaml:"level"`
+	On    string `yaml:"on"`
+	// One of: `low`, `normal`, `critical`
+	Urgency string        `yaml:"urgency"`
+	Summary *ConfigFormat `yaml:"summary"`
+	Body    *ConfigFormat `yaml:"body"`
+	// Shell command to run along with the notification
+	Exec string `yaml:"exec"`
+}
+
+const (
+	batteryBackendUpower = "upower"
+	batteryBackendSysfs  = "sysfs"
+)
+
+// Weight of a new sample in the exponential moving average of power draw
+const batteryRateSmoothing = 0.2
+
+type upowerDevice struct {
+	path             dbus.ObjectPath
+	devType          uint32
+	model            string
+	powerSupply      bool
+	isPresent        bool
+	percentage       float64
+	timeToEmpty      int64
+	timeToFull       int64
+	state            uint32
+	energy           float64
+	energyFull       float64
+	energyFullDesign float64
+	energyRate       float64
+	capacity         float64
+	// Used for time estimates when UPower doesn't provide them
+	smoothedRate   float64
+	lastEnergy     float64
+	lastState      uint32
+	lastSampleTime time.Time
+}
+
+func (d *upowerDevice) propMap() map[string]interface{} {
+	return map[string]interface{}{
+		"Type":             &d.devType,
+		"Model":            &d.model,
+		"PowerSupply":      &d.powerSupply,
+		"IsPresent":        &d.isPresent,
+		"Percentage":       &d.percentage,
+		"TimeToEmpty":      &d.timeToEmpty,
+		"TimeToFull":       &d.timeToFull,
+		"State":            &d.state,
+		"Energy":           &d.energy,
+		"EnergyFull":       &d.energyFull,
+		"EnergyFullDesign": &d.energyFullDesign,
+		"EnergyRate":       &d.energyRate,
+		"Capacity":         &d.capacity,
+	}
+}
+
+// Updates the smoothed power draw. If UPower reports no `EnergyRate`, it is
+// derived from the energy change since the previous sample
+func (d *upowerDevice) sampleRate(now time.Time) {
+	if d.state != d.lastState {
+		d.smoothedRate = 0
+		d.lastState = d.state
+		d.lastSampleTime = time.Time{}
+	}
+	rate := d.energyRate
+	if rate <= 0 && !d.lastSampleTime.IsZero() && d.energy != d.lastEnergy {
+		if hours := now.Sub(d.lastSampleTime).Hours(); hours > 0 {
+			rate = math.Abs(d.energy-d.lastEnergy) / hours
+		}
+	}
+	if d.lastSampleTime.IsZero() || d.energy != d.lastEnergy {
+		d.lastEnergy, d.lastSampleTime = d.energy, now
+	}
+	if rate <= 0 {
+		return
+	}
+	if d.smoothedRate == 0 {
+		d.smoothedRate = rate
+	} else {
+		d.smoothedRate += batteryRateSmoothing * (rate - d.smoothedRate)
+	}
+}
+
+func (d *upowerDevice) status() *batteryStatus {
+	return &batteryStatus{
+		percentage:       int(math.Round(d.percentage)),
+		state:            d.state,
+		timeToEmpty:      d.timeToEmpty,
+		timeToFull:       d.timeToFull,
+		energy:           d.energy,
+		energyFull:       d.energyFull,
+		energyFullDesign: d.energyFullDesign,
+		energyRate:       d.energyRate,
+		smoothedRate:     d.smoothedRate,
+		capacity:         d.capacity,
+	}
+}
+
+func (d *upowerDevice) load(conn *dbus.Conn) error {
+	obj := conn.Object(upowerDbusDest, d.path)
+	var props map[string]dbus.Variant
+	if err := obj.Call(
+		dbusPropertiesIface+".GetAll", 0, upowerDbusDest+".Device",
+	).Store(&props); err != nil {
+		return err
+	}
+	d.update(props)
+	return nil
+}
+
+func (d *upowerDevice) update(props map[string]dbus.Variant) {
+	refs := d.propMap()
+	for k, v := range props {
+		if ref, ok := refs[k]; ok {
+			v.Store(ref)
+		}
+	}
+	d.sampleRate(time.Now())
+}
+
+// Battery state to be rendered, possibly combined from several devices
+type batteryStatus struct {
+	percentage               int
+	state                    uint32
+	timeToEmpty, timeToFull  int64
+	energy, energyFull       float64
+	energyFullDesign         float64
+	energyRate, smoothedRate float64
+	capacity                 float64
+}
+
+// Fills missing time estimates from the smoothed power draw
+func (st *batteryStatus) estimate() {
+	if st.smoothedRate <= 0 {
+		return
+	}
+	hoursToSeconds := func(h float64) int64 {
+		return int64(h * 3600)
+	}
+	switch st.state {
+	case upowerStateDischarging:
+		if st.timeToEmpty == 0 {
+			st.timeToEmpty = hoursToSeconds(st.energy / st.smoothedRate)
+		}
+	case upowerStateCharging:
+		if st.timeToFull == 0 && st.energyFull > st.energy {
+			st.timeToFull = hoursToSeconds(
+				(st.energyFull - st.energy) / st.smoothedRate,
+			)
+		}
+	}
+}
+
+// Battery health, i.e. current full capacity relative to design capacity
+func (st *batteryStatus) health() int {
+	if st.energyFullDesign > 0 {
+		return int(math.Round(st.energyFull / st.energyFullDesign * 100))
+	}
+	return int(math.Round(st.capacity))
 }
 
 type BatteryBlock struct {
 	BatteryBlockConfig
-	percentage  int
-	timeToEmpty int64
-	state       uint32
-	dbusConn    *dbus.Conn
-	propMap     map[string]interface{}
-	available   bool
+	dbusConn  *dbus.Conn
+	devices   []*upowerDevice
+	available bool
+	onBattery *bool
+	// State as of the last notification check, to detect transitions
+	notifier          *desktopNotifier
+	notifiedState     *uint32
+	notifiedOnBattery *bool
+	firedRules        map[int]bool
 }
 
 func NewBatteryBlock() I3barBlocklet {
 	b := BatteryBlock{}
+	defInterval := ConfigInterval(10 * time.Second)
+	b.Interval = &defInterval
+	b.Backend = batteryBackendUpower
+	b.SysfsRoot = sysfs.PowerSupplyRoot
 	b.Format = NewConfigFormatFromString("{state_icon} {percentage}%")
-	b.propMap = map[string]interface{}{
-		"Percentage":  &b.percentage,
-		"TimeToEmpty": &b.timeToEmpty,
-		"State":       &b.state,
-	}
+	b.DeviceFormat = NewConfigFormatFromString("{icon}{percentage}%")
 	b.available = false
 	return &b
 }
@@ -73,32 +305,35 @@ func (t *BatteryBlock) GetConfig() interface{} {
 	return &t.BatteryBlockConfig
 }
 
-func (t *BatteryBlock) getLevelIcon() string {
-	x := float64(t.percentage) / 100.1 * float64(len(t.LevelIcons))
+func (t *BatteryBlock) getLevelIcon(percentage int) string {
+	if len(t.LevelIcons) == 0 {
+		return ""
+	}
+	x := float64(percentage) / 100.1 * float64(len(t.LevelIcons))
 	return t.LevelIcons[int(x)]
 }
 
-func (t *BatteryBlock) getStateIcon() string {
-	switch t.state {
+func (t *BatteryBlock) getStateIcon(st *batteryStatus) string {
+	switch st.state {
 	case upowerStateUnknown:
 		return t.StateIcons["unknown"]
 	case upowerStateCharging:
 		if icon, ok := t.StateIcons["discharging"]; ok {
 			return icon
 		}
-		return t.getLevelIcon()
+		return t.getLevelIcon(st.percentage)
 	case upowerStateDischarging:
 		if icon, ok := t.StateIcons["discharging"]; ok {
 			return icon
 		}
-		return t.getLevelIcon()
+		return t.getLevelIcon(st.percentage)
 	case upowerStateEmpty:
 		return t.StateIcons["empty"]
 	case upowerStateFullyCharged:
 		if icon, ok := t.StateIcons["fully_charged"]; ok {
 			return icon
 		}
-		return t.getLevelIcon()
+		return t.getLevelIcon(st.percentage)
 	case upowerStatePendingCharge:
 		// TODO: how to handle this states?
 		return t.StateIcons["pending_charge"]
@@ -139,39 +374,234 @@ func (t *BatteryBlock) findLaptopBattery(ctx context.Context) (p dbus.ObjectPath
 	return
 }
 
-func (t *BatteryBlock) loadInitial() (err error) {
-	obj := t.dbusConn.Object(upowerDbusDest, dbus.ObjectPath(t.UpowerDevice))
-	iface := upowerDbusDest + ".Device"
-	for k, v := range t.propMap {
-		if err = obj.Call(dbusGetProperty, 0, iface, k).Store(v); err != nil {
+// Whether the device should be displayed in current mode
+func (t *BatteryBlock) wantsDevice(d *upowerDevice) bool {
+	switch t.Mode {
+	case batteryModeAggregate:
+		return d.devType == deviceTypeBattery && d.powerSupply
+	case batteryModeDevices:
+		switch d.devType {
+		case deviceTypeUnknown, deviceTypeLinePower:
+			return false
+		case deviceTypeBattery:
+			return !d.powerSupply
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+func (t *BatteryBlock) loadDevices(ctx context.Context) error {
+	var paths []dbus.ObjectPath
+	switch t.Mode {
+	case batteryModeAggregate, batteryModeDevices:
+		var err error
+		if paths, err = t.listDevices(ctx); err != nil {
+			return err
+		}
+	case batteryModeDisplayDevice:
+		paths = []dbus.ObjectPath{upowerDisplayDevice}
+	default:
+		paths = []dbus.ObjectPath{dbus.ObjectPath(t.UpowerDevice)}
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i] < paths[j] })
+	devices := make([]*upowerDevice, 0, len(paths))
+	for _, p := range paths {
+		d := &upowerDevice{path: p}
+		if err := d.load(t.dbusConn); err != nil {
+			return err
+		}
+		if t.wantsDevice(d) {
+			devices = append(devices, d)
+		}
+	}
+	t.devices = devices
+	return nil
+}
+
+func (t *BatteryBlock) findDevice(p dbus.ObjectPath) *upowerDevice {
+	for _, d := range t.devices {
+		if d.path == p {
+			return d
+		}
+	}
+	return nil
+}
+
+// Whether DeviceAdded/DeviceRemoved for the path should reload devices
+func (t *BatteryBlock) tracksPath(p dbus.ObjectPath) bool {
+	switch t.Mode {
+	case batteryModeAggregate, batteryModeDevices:
+		return true
+	default:
+		return t.findDevice(p) != nil || p == dbus.ObjectPath(t.UpowerDevice)
+	}
+}
+
+func (t *BatteryBlock) reload(ctx context.Context) {
+	if err := t.loadDevices(ctx); err != nil {
+		t.available = false
+		Log.Print(err)
+	} else {
+		t.available = len(t.devices) > 0 || t.Mode == batteryModeDevices
+	}
+}
+
+func sysfsStatusToUpower(status string) uint32 {
+	switch status {
+	case "Charging":
+		return upowerStateCharging
+	case "Discharging":
+		return upowerStateDischarging
+	case "Full":
+		return upowerStateFullyCharged
+	case "Not charging":
+		return upowerStatePendingCharge
+	default:
+		return upowerStateUnknown
+	}
+}
+
+// Converts sysfs battery to the UPower model. The sysfs name is used in place
+// of the object path
+func (d *upowerDevice) loadPowerSupply(p *sysfs.PowerSupply) {
+	d.devType = deviceTypeBattery
+	d.model = p.ModelName
+	d.powerSupply = p.IsSystem()
+	d.isPresent = true
+	d.percentage = p.Capacity
+	d.state = sysfsStatusToUpower(p.Status)
+	d.energy = p.EnergyNow
+	d.energyFull = p.EnergyFull
+	d.energyFullDesign = p.EnergyFullDesign
+	d.energyRate = p.PowerNow
+	if p.EnergyFullDesign > 0 {
+		d.capacity = p.EnergyFull / p.EnergyFullDesign * 100
+	}
+	d.sampleRate(time.Now())
+}
+
+func (t *BatteryBlock) loadSysfs() error {
+	supplies, err := sysfs.ReadPowerSupplies(t.SysfsRoot)
+	if err != nil {
+		return err
+	}
+	acOnline, hasAc := false, false
+	for _, p := range supplies {
+		if p.Type == sysfs.PowerSupplyTypeMains {
+			hasAc = true
+			acOnline = acOnline || p.Online
+		}
+	}
+	if hasAc {
+		onBattery := !acOnline
+		t.onBattery = &onBattery
+	}
+	devices := []*upowerDevice{}
+	for i := range supplies {
+		p := &supplies[i]
+		if p.Type != sysfs.PowerSupplyTypeBattery {
+			continue
+		}
+		if t.Mode == batteryModeDevice {
+			if t.UpowerDevice != "" && p.Name != t.UpowerDevice ||
+				t.UpowerDevice == "" && (!p.IsSystem() || len(devices) > 0) {
+				continue
+			}
+		}
+		path := dbus.ObjectPath(p.Name)
+		// Reuse existing devices to keep power draw smoothing
+		d := t.findDevice(path)
+		if d == nil {
+			d = &upowerDevice{path: path}
+		}
+		d.loadPowerSupply(p)
+		if d.state == upowerStateUnknown && d.powerSupply && acOnline {
+			d.state = upowerStatePendingCharge
+		}
+		if t.Mode == batteryModeDisplayDevice && !d.powerSupply ||
+			!t.wantsDevice(d) {
+			continue
+		}
+		devices = append(devices, d)
+	}
+	t.devices = devices
+	return nil
+}
+
+func (t *BatteryBlock) runSysfs(ch UpdateChan, ctx context.Context) {
+	t.notifier = newDesktopNotifier("gost")
+	defer t.notifier.Close()
+	var uevents <-chan struct{}
+	if t.Uevents {
+		var err error
+		if uevents, err = sysfs.ListenUevents(ctx, "power_supply"); err != nil {
+			Log.Print(err)
+		}
+	}
+	ticker := time.NewTicker(time.Duration(*t.Interval))
+	defer ticker.Stop()
+	for {
+		if err := t.loadSysfs(); err != nil {
+			Log.Print(err)
+			t.available = false
+		} else {
+			t.available = len(t.devices) > 0 || t.Mode == batteryModeDevices
+		}
+		t.checkNotifications(ctx)
+		ch.SendUpdate()
+		select {
+		case <-ctx.Done():
 			return
+		case <-ticker.C:
+		case _, ok := <-uevents:
+			if !ok {
+				uevents = nil
+			}
 		}
 	}
-	return
 }
 
 func (t *BatteryBlock) Run(ch UpdateChan, ctx context.Context) {
+	if t.Backend == batteryBackendSysfs {
+		t.runSysfs(ch, ctx)
+		return
+	}
 	b, err := dbus.ConnectSystemBus()
 	if err != nil {
 		Log.Println("ConnectSystemBus", err)
 		return
 	}
 	t.dbusConn = b
-	if t.UpowerDevice == "" {
-		p, err := t.findLaptopBattery(ctx)
-		if err != nil {
-			Log.Print(err)
-			return
+	t.notifier = newDesktopNotifier("gost")
+	defer t.notifier.Close()
+	if t.Mode == batteryModeDevice {
+		if t.UpowerDevice == "" {
+			p, err := t.findLaptopBattery(ctx)
+			if err != nil {
+				Log.Print(err)
+				return
+			}
+			t.UpowerDevice = string(p)
+		} else {
+			t.UpowerDevice = "/org/freedesktop/UPower/devices/" + t.UpowerDevice
 		}
-		t.UpowerDevice = string(p)
-	} else {
-		t.UpowerDevice = "/org/freedesktop/UPower/devices/" + t.UpowerDevice
 	}
 	defer b.Close()
 
 	if err := b.AddMatchSignalContext(
 		ctx,
-		dbus.WithMatchObjectPath(dbus.ObjectPath(t.UpowerDevice)),
+		dbus.WithMatchPathNamespace(upowerDbusBasePath+"/devices"),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+	); err != nil {
+		Log.Print(err)
+		return
+	}
+	if err := b.AddMatchSignalContext(
+		ctx,
+		dbus.WithMatchObjectPath(upowerDbusBasePath),
 		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
 		dbus.WithMatchMember("PropertiesChanged"),
 	); err != nil {
@@ -199,66 +629,252 @@ func (t *BatteryBlock) Run(ch UpdateChan, ctx context.Context) {
 
 	c := make(chan *dbus.Signal)
 	b.Signal(c)
-	if err := t.loadInitial(); err != nil {
-		t.available = false
+	var onBattery bool
+	if err := b.Object(upowerDbusDest, upowerDbusBasePath).Call(
+		dbusGetProperty, 0, upowerDbusDest, "OnBattery",
+	).Store(&onBattery); err != nil {
 		Log.Print(err)
 	} else {
-		t.available = true
-		ch.SendUpdate()
+		t.onBattery = &onBattery
 	}
+	t.reload(ctx)
+	t.checkNotifications(ctx)
+	ch.SendUpdate()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case s := <-c:
-			if s.Path == dbus.ObjectPath(t.UpowerDevice) {
-				if !t.available {
+			switch s.Name {
+			case "org.freedesktop.DBus.Properties.PropertiesChanged":
+				props := s.Body[1].(map[string]dbus.Variant)
+				if s.Path == upowerDbusBasePath {
+					if v, ok := props["OnBattery"]; ok {
+						var onBattery bool
+						v.Store(&onBattery)
+						t.onBattery = &onBattery
+						t.checkNotifications(ctx)
+					}
 					continue
 				}
-				changedProps := s.Body[1].(map[string]dbus.Variant)
-				for k, v := range changedProps {
-					if ref, ok := t.propMap[k]; ok {
-						v.Store(ref)
-					}
+				d := t.findDevice(s.Path)
+				if d == nil || !t.available {
+					continue
 				}
+				d.update(props)
+				t.checkNotifications(ctx)
 				ch.SendUpdate()
-			} else if s.Path == upowerDbusBasePath &&
-				s.Name == "org.freedesktop.UPower.DeviceAdded" &&
-				dbus.ObjectPath(t.UpowerDevice) == s.Body[0].(dbus.ObjectPath) {
-				if err := t.loadInitial(); err != nil {
-					Log.Print(err)
-				} else {
-					t.available = true
+			case "org.freedesktop.UPower.DeviceAdded",
+				"org.freedesktop.UPower.DeviceRemoved":
+				if p, ok := s.Body[0].(dbus.ObjectPath); ok && t.tracksPath(p) {
+					t.reload(ctx)
 					ch.SendUpdate()
 				}
-			} else if s.Path == upowerDbusBasePath &&
-				s.Name == "org.freedesktop.UPower.DeviceRemoved" &&
-				dbus.ObjectPath(t.UpowerDevice) == s.Body[0].(dbus.ObjectPath) {
-				t.available = false
-				ch.SendUpdate()
 			}
 		}
 	}
 }
 
+// Combines the devices into a single status. Percentage is weighted by
+// energy, so batteries of different capacity are accounted properly
+func (t *BatteryBlock) aggregateStatus() *batteryStatus {
+	if len(t.devices) == 1 {
+		return t.devices[0].status()
+	}
+	st := &batteryStatus{state: upowerStateFullyCharged}
+	for _, d := range t.devices {
+		st.energy += d.energy
+		st.energyFull += d.energyFull
+		st.energyFullDesign += d.energyFullDesign
+		st.energyRate += d.energyRate
+		st.smoothedRate += d.smoothedRate
+		switch {
+		case d.state == upowerStateCharging:
+			st.state = upowerStateCharging
+		case d.state == upowerStateDischarging &&
+			st.state != upowerStateCharging:
+			st.state = upowerStateDischarging
+		case d.state != upowerStateFullyCharged &&
+			st.state == upowerStateFullyCharged:
+			st.state = d.state
+		}
+	}
+	if st.energyFull > 0 {
+		st.percentage = int(math.Round(st.energy / st.energyFull * 100))
+	}
+	// Per-device times don't add up, so they're always estimated from the
+	// total energy and power draw
+	return st
+}
+
+func (t *BatteryBlock) fireNotification(
+	ctx context.Context,
+	rule *BatteryNotifyRule,
+	st *batteryStatus,
+) {
+	args := t.baseArgs(st)
+	summary, body := "Battery", fmt.Sprintf("%d%%", st.percentage)
+	if rule.Summary != nil {
+		summary = rule.Summary.Expand(args)
+	}
+	if rule.Body != nil {
+		body = rule.Body.Expand(args)
+	}
+	if err := t.notifier.Notify(summary, body, rule.Urgency); err != nil {
+		Log.Print(err)
+	}
+	if rule.Exec != "" {
+		cmd := exec.CommandContext(ctx, "sh", "-c", rule.Exec)
+		cmd.Stdout, cmd.Stderr = Log.Writer(), Log.Writer()
+		if err := cmd.Start(); err != nil {
+			Log.Print(err)
+			return
+		}
+		// Don't block the update loop on a long-running command
+		go func() {
+			if err := cmd.Wait(); err != nil {
+				Log.Print(err)
+			}
+		}()
+	}
+}
+
+// Returns rules triggered since the previous check and remembers the state
+// for the next one. The first check only records the state, so nothing fires
+// when the blocklet starts or the config is reloaded
+func (t *BatteryBlock) dueNotifications(st *batteryStatus) []*BatteryNotifyRule {
+	if t.firedRules == nil {
+		t.firedRules = make(map[int]bool)
+	}
+	prevState, prevOnBattery := t.notifiedState, t.notifiedOnBattery
+	due := []*BatteryNotifyRule{}
+	for i := range t.Notifications {
+		rule := &t.Notifications[i]
+		fire := false
+		if rule.Level != nil {
+			below := st.state == upowerStateDischarging &&
+				st.percentage <= *rule.Level
+			fire = below && !t.firedRules[i] && prevState != nil
+			t.firedRules[i] = below
+		} else {
+			switch rule.On {
+			case batteryEventFullyCharged:
+				fire = prevState != nil &&
+					*prevState != upowerStateFullyCharged &&
+					st.state == upowerStateFullyCharged
+			case batteryEventAcPlugged:
+				fire = prevOnBattery != nil && t.onBattery != nil &&
+					*prevOnBattery && !*t.onBattery
+			case batteryEventAcUnplugged:
+				fire = prevOnBattery != nil && t.onBattery != nil &&
+					!*prevOnBattery && *t.onBattery
+			}
+		}
+		if fire {
+			due = append(due, rule)
+		}
+	}
+	state := st.state
+	t.notifiedState = &state
+	if t.onBattery != nil {
+		onBattery := *t.onBattery
+		t.notifiedOnBattery = &onBattery
+	}
+	return due
+}
+
+// Sends notifications for rules triggered since the previous check
+func (t *BatteryBlock) checkNotifications(ctx context.Context) {
+	if len(t.Notifications) == 0 || t.Mode == batteryModeDevices ||
+		!t.available || len(t.devices) == 0 {
+		return
+	}
+	st := t.aggregateStatus()
+	st.estimate()
+	for _, rule := range t.dueNotifications(st) {
+		t.fireNotification(ctx, rule, st)
+	}
+}
+
+// Placeholders that don't depend on the theme, shared with notifications
+func (t *BatteryBlock) baseArgs(st *batteryStatus) formatting.NamedArgs {
+	st.estimate()
+	var remaining int64
+	switch st.state {
+	case upowerStateCharging:
+		remaining = st.timeToFull
+	case upowerStateDischarging:
+		remaining = st.timeToEmpty
+	}
+	formatSeconds := func(s int64) string {
+		if s <= 0 {
+			return ""
+		}
+		return HumanDuration(time.Duration(s) * time.Second)
+	}
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 1, 64)
+	}
+	args := formatting.NamedArgs{
+		"percentage":         st.percentage,
+		"time_to_empty":      formatSeconds(st.timeToEmpty),
+		"time_to_full":       formatSeconds(st.timeToFull),
+		"time":               formatSeconds(remaining),
+		"power":              formatFloat(st.energyRate),
+		"energy":             formatFloat(st.energy),
+		"energy_full":        formatFloat(st.energyFull),
+		"energy_full_design": formatFloat(st.energyFullDesign),
+		"health":             st.health(),
+		"capacity":           int(math.Round(st.capacity)),
+	}
+	return args
+}
+
+func (t *BatteryBlock) statusArgs(
+	cfg *AppConfig,
+	st *batteryStatus,
+) formatting.NamedArgs {
+	args := t.baseArgs(st)
+	args["state_icon"] = fmt.Sprintf(
+		`<span color="%v">%s</span>`,
+		cfg.Theme.HSVColor(PercentageToHue(st.percentage)),
+		t.getStateIcon(st),
+	)
+	if st.state == upowerStateCharging {
+		args["is_charging"] = t.StateIcons["charging"]
+	}
+	return args
+}
+
 func (t *BatteryBlock) Render(cfg *AppConfig) []I3barBlock {
 	if !t.available {
 		return nil
 	}
-	args := formatting.NamedArgs{
-		"percentage":    t.percentage,
-		"time_to_empty": t.timeToEmpty,
-		"state_icon": fmt.Sprintf(
-			`<span color="%v">%s</span>`,
-			cfg.Theme.HSVColor(PercentageToHue(t.percentage)),
-			t.getStateIcon(),
-		),
-	}
-	if t.state == upowerStateCharging {
-		args["is_charging"] = t.StateIcons["charging"]
+	if t.Mode == batteryModeDevices {
+		blocks := make([]I3barBlock, 0, len(t.devices))
+		for _, d := range t.devices {
+			st := d.status()
+			args := t.statusArgs(cfg, st)
+			args["icon"] = t.DeviceIcons[upowerDeviceTypeNames[d.devType]]
+			args["model"] = d.model
+			b := I3barBlock{
+				FullText: t.DeviceFormat.Expand(args),
+				Instance: string(d.path),
+				Markup:   MarkupPango,
+			}
+			if t.UrgentLevel != nil && st.percentage <= *t.UrgentLevel {
+				b.Urgent = true
+			}
+			blocks = append(blocks, b)
+		}
+		return blocks
+	}
+	if len(t.devices) == 0 {
+		return nil
 	}
-	b := I3barBlock{FullText: t.Format.Expand(args)}
-	if t.UrgentLevel != nil && t.percentage <= *t.UrgentLevel {
+	st := t.aggregateStatus()
+	b := I3barBlock{FullText: t.Format.Expand(t.statusArgs(cfg, st))}
+	if t.UrgentLevel != nil && st.percentage <= *t.UrgentLevel {
 		b.Urgent = true
 	}
 	b.Markup = MarkupPango
@@ -0,0 +1,85 @@
+package blocks
+
+import "testing"
+
+func TestBatteryNotificationTransitions(t *testing.T) {
+	level := 20
+	type sample struct {
+		state      uint32
+		percentage int
+		onBattery  bool
+		fire       bool
+	}
+	cases := []struct {
+		name    string
+		rule    BatteryNotifyRule
+		samples []sample
+	}{
+		{
+			name: "low level",
+			rule: BatteryNotifyRule{Level: &level},
+			samples: []sample{
+				// Already low at startup: nothing fires
+				{upowerStateDischarging, 15, true, false},
+				{upowerStateDischarging, 14, true, false},
+				{upowerStateCharging, 16, false, false},
+				{upowerStateDischarging, 16, true, true},
+				{upowerStateDischarging, 10, true, false},
+			},
+		},
+		{
+			name: "level crossed",
+			rule: BatteryNotifyRule{Level: &level},
+			samples: []sample{
+				{upowerStateDischarging, 25, true, false},
+				{upowerStateDischarging, 21, true, false},
+				{upowerStateDischarging, 20, true, true},
+				{upowerStateDischarging, 19, true, false},
+			},
+		},
+		{
+			name: "fully charged",
+			rule: BatteryNotifyRule{On: batteryEventFullyCharged},
+			samples: []sample{
+				{upowerStateFullyCharged, 100, false, false},
+				{upowerStateDischarging, 99, true, false},
+				{upowerStateCharging, 99, false, false},
+				{upowerStateFullyCharged, 100, false, true},
+				{upowerStateFullyCharged, 100, false, false},
+			},
+		},
+		{
+			name: "ac unplugged",
+			rule: BatteryNotifyRule{On: batteryEventAcUnplugged},
+			samples: []sample{
+				{upowerStateDischarging, 50, true, false},
+				{upowerStateCharging, 50, false, false},
+				{upowerStateDischarging, 50, true, true},
+			},
+		},
+		{
+			name: "ac plugged",
+			rule: BatteryNotifyRule{On: batteryEventAcPlugged},
+			samples: []sample{
+				{upowerStateCharging, 50, false, false},
+				{upowerStateDischarging, 50, true, false},
+				{upowerStateCharging, 50, false, true},
+			},
+		},
+	}
+	for _, c := range cases {
+		b := NewBatteryBlock().(*BatteryBlock)
+		b.Notifications = []BatteryNotifyRule{c.rule}
+		for i, s := range c.samples {
+			onBattery := s.onBattery
+			b.onBattery = &onBattery
+			due := b.dueNotifications(&batteryStatus{
+				state:      s.state,
+				percentage: s.percentage,
+			})
+			if fired := len(due) > 0; fired != s.fire {
+				t.Errorf("%s, sample %d: expected fired=%v, got %v", c.name, i, s.fire, fired)
+			}
+		}
+	}
+}
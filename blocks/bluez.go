@@ -2,6 +2,7 @@ package blocks
 
 import (
 	"context"
+	"sort"
 	"strings"
 
 	"github.com/godbus/dbus/v5"
@@ -11,33 +12,89 @@ import (
 
 type bluezObjectManagerOutput map[dbus.ObjectPath](map[string](map[string]dbus.Variant))
 
-// Displays connected bluetooth devices
+const (
+	bluezDbusDest     = "org.bluez"
+	bluezAdapterIface = "org.bluez.Adapter1"
+	bluezDeviceIface  = "org.bluez.Device1"
+	bluezBatteryIface = "org.bluez.Battery1"
+)
+
+const (
+	bluezStateOff         = "off"
+	bluezStateOn          = "on"
+	bluezStateDiscovering = "discovering"
+)
+
+const bluezAdapterInstance = "adapter"
+
+// Displays connected bluetooth devices in a single block. If `adapter_format`
+// is set, adapter state is shown instead, followed by a block per device:
+// clicking a device connects or disconnects it. Middle click toggles adapter
+// power. Favourites go first in the order they are configured, the rest are
+// sorted by alias
 type BluezBlockConfig struct {
 	BaseBlockletConfig `yaml:",inline"`
-	// Mac address of the device
-	Device             string            `yaml:"mac"`
-	Format             *ConfigFormat     `yaml:"format"`
-	// Device format
-	DeviceFormat       *ConfigFormat     `yaml:"device_format"`
-	Icons              map[string]string `yaml:"icons"`
-	ExcludeMac         []string          `yaml:"exclude"`
+	// Mac address of the device. If set, only this device is shown
+	Device string `yaml:"mac"`
+	// Placeholders: `{devices}` (connected devices, joined by space),
+	// `{state}`, `{icon}`, `{name}`, `{count}`
+	Format *ConfigFormat `yaml:"format"`
+	// Format of the adapter block, shown along with a block per device.
+	// Same placeholders as `format`, except `{devices}`
+	AdapterFormat *ConfigFormat `yaml:"adapter_format"`
+	// Device format. Placeholders: `{icon}`, `{name}`, `{alias}`, `{mac}`,
+	// `{state}`, `{battery}`
+	DeviceFormat *ConfigFormat     `yaml:"device_format"`
+	Icons        map[string]string `yaml:"icons"`
+	// Icons for adapter `{icon}`, keyed by `on`, `off` or `discovering`
+	AdapterIcons map[string]string `yaml:"adapter_icons"`
+	ExcludeMac   []string          `yaml:"exclude"`
+	// Mac addresses of devices which are shown even when disconnected,
+	// so they can be connected with a click
+	Favourites []string `yaml:"favourites"`
+	// Battery percentage below which the device block is colored
+	LowBattery int `yaml:"low_battery"`
 }
 
 type bluezDevice struct {
 	path              dbus.ObjectPath
 	connected         bool
 	name, alias, icon string
+	address           string
+	// Battery percentage, -1 if the device doesn't report it
+	battery int
+}
+
+type bluezAdapter struct {
+	path        dbus.ObjectPath
+	alias       string
+	powered     bool
+	discovering bool
+}
+
+func (a *bluezAdapter) state() string {
+	switch {
+	case !a.powered:
+		return bluezStateOff
+	case a.discovering:
+		return bluezStateDiscovering
+	default:
+		return bluezStateOn
+	}
 }
 
 type BluezBlock struct {
 	BluezBlockConfig
 	dbus    *dbus.Conn
 	devices map[dbus.ObjectPath]*bluezDevice
+	adapter *bluezAdapter
 }
 
 func NewBluezBlock() I3barBlocklet {
 	b := BluezBlock{}
+	b.Format = NewConfigFormatFromString("{devices}")
 	b.DeviceFormat = NewConfigFormatFromString("{icon}")
+	b.LowBattery = 20
 	return &b
 }
 
@@ -46,14 +103,54 @@ func (t *BluezBlock) GetConfig() interface{} {
 }
 
 func (b *BluezBlock) isExcluded(addr string) bool {
+	if b.Device != "" && !strings.EqualFold(b.Device, addr) {
+		return true
+	}
 	for _, p := range b.ExcludeMac {
-		if p == addr {
+		if strings.EqualFold(p, addr) {
 			return true
 		}
 	}
 	return false
 }
 
+// Position of the device in `favourites`, -1 if it's not there
+func (b *BluezBlock) favouriteIndex(addr string) int {
+	for i, f := range b.Favourites {
+		if strings.EqualFold(f, addr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *BluezBlock) isFavourite(addr string) bool {
+	return b.favouriteIndex(addr) != -1
+}
+
+func (t *BluezBlock) sortedDevices() []*bluezDevice {
+	devices := make([]*bluezDevice, 0, len(t.devices))
+	for _, d := range t.devices {
+		devices = append(devices, d)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		a, b := devices[i], devices[j]
+		fa, fb := t.favouriteIndex(a.address), t.favouriteIndex(b.address)
+		if fa != fb {
+			if fa == -1 || fb == -1 {
+				return fb == -1
+			}
+			return fa < fb
+		}
+		la, lb := strings.ToLower(a.alias), strings.ToLower(b.alias)
+		if la != lb {
+			return la < lb
+		}
+		return a.address < b.address
+	})
+	return devices
+}
+
 func (b *BluezBlock) loadDevices() (err error) {
 	var bluezObjects bluezObjectManagerOutput
 	if err = b.dbus.Object("org.bluez", "/").Call(
@@ -62,8 +159,18 @@ func (b *BluezBlock) loadDevices() (err error) {
 		return
 	}
 	b.devices = make(map[dbus.ObjectPath]*bluezDevice)
+	b.adapter = nil
 	for path, v := range bluezObjects {
-		if info, ok := v["org.bluez.Device1"]; ok {
+		// Only one adapter is used, usually hci0
+		if info, ok := v[bluezAdapterIface]; ok &&
+			(b.adapter == nil || path < b.adapter.path) {
+			a := bluezAdapter{path: path}
+			info["Alias"].Store(&a.alias)
+			info["Powered"].Store(&a.powered)
+			info["Discovering"].Store(&a.discovering)
+			b.adapter = &a
+		}
+		if info, ok := v[bluezDeviceIface]; ok {
 			var addr, name, alias, icon string
 			var connected bool
 			info["Address"].Store(&addr)
@@ -76,7 +183,15 @@ func (b *BluezBlock) loadDevices() (err error) {
 			info["Name"].Store(&name)
 			info["Alias"].Store(&alias)
 			info["Connected"].Store(&connected)
-			b.devices[path] = &bluezDevice{path, connected, name, alias, icon}
+			battery := -1
+			if bat, ok := v[bluezBatteryIface]; ok {
+				var percentage byte
+				bat["Percentage"].Store(&percentage)
+				battery = int(percentage)
+			}
+			b.devices[path] = &bluezDevice{
+				path, connected, name, alias, icon, addr, battery,
+			}
 		}
 	}
 	return
@@ -88,21 +203,37 @@ func (t *BluezBlock) addSignals() (err error) {
 		dbus.WithMatchPathNamespace("/org/bluez"),
 		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
 		dbus.WithMatchMember("PropertiesChanged"),
-		dbus.WithMatchArg(0, "org.bluez.Device1"),
+		dbus.WithMatchArg(0, bluezDeviceIface),
+	); err != nil {
+		return
+	}
+	if err = b.AddMatchSignal(
+		dbus.WithMatchPathNamespace("/org/bluez"),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, bluezAdapterIface),
+	); err != nil {
+		return
+	}
+	if err = b.AddMatchSignal(
+		dbus.WithMatchPathNamespace("/org/bluez"),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, bluezBatteryIface),
 	); err != nil {
 		return
 	}
 	if err = b.AddMatchSignal(
 		dbus.WithMatchObjectPath("/"),
 		dbus.WithMatchInterface("org.freedesktop.DBus.ObjectManager"),
-		dbus.WithMatchMember("InterfaceAdded"),
+		dbus.WithMatchMember("InterfacesAdded"),
 	); err != nil {
 		return
 	}
 	if err = b.AddMatchSignal(
 		dbus.WithMatchObjectPath("/"),
 		dbus.WithMatchInterface("org.freedesktop.DBus.ObjectManager"),
-		dbus.WithMatchMember("InterfaceRemoved"),
+		dbus.WithMatchMember("InterfacesRemoved"),
 	); err != nil {
 		return
 	}
@@ -134,11 +265,28 @@ func (t *BluezBlock) Run(ch UpdateChan, ctx context.Context) {
 			return
 		case s := <-c:
 			switch s.Name {
-			case "org.freedesktop.DBus.ObjectManager.InterfaceAdded",
-				"org.freedesktop.DBus.ObjectManager.InterfaceRemoved":
+			case "org.freedesktop.DBus.ObjectManager.InterfacesAdded",
+				"org.freedesktop.DBus.ObjectManager.InterfacesRemoved":
 				t.loadDevices()
 				ch.SendUpdate()
 			case "org.freedesktop.DBus.Properties.PropertiesChanged":
+				if iface, _ := s.Body[0].(string); iface == bluezAdapterIface {
+					if t.adapter == nil || t.adapter.path != s.Path {
+						break
+					}
+					props := s.Body[1].(map[string]dbus.Variant)
+					if v, ok := props["Powered"]; ok {
+						v.Store(&t.adapter.powered)
+					}
+					if v, ok := props["Discovering"]; ok {
+						v.Store(&t.adapter.discovering)
+					}
+					if v, ok := props["Alias"]; ok {
+						v.Store(&t.adapter.alias)
+					}
+					ch.SendUpdate()
+					break
+				}
 				if t.devices[s.Path] == nil {
 					t.loadDevices()
 					ch.SendUpdate()
@@ -151,28 +299,123 @@ func (t *BluezBlock) Run(ch UpdateChan, ctx context.Context) {
 					t.devices[s.Path].connected = connected
 					ch.SendUpdate()
 				}
+				if p, ok := props["Percentage"]; ok {
+					var percentage byte
+					p.Store(&percentage)
+					t.devices[s.Path].battery = int(percentage)
+					ch.SendUpdate()
+				}
 			}
 		}
 	}
 }
 
-func (b *BluezBlock) Render(cfg *AppConfig) []I3barBlock {
-	labels := []string{}
+func (t *BluezBlock) findDevice(addr string) *bluezDevice {
+	for _, d := range t.devices {
+		if d.address == addr {
+			return d
+		}
+	}
+	return nil
+}
+
+func (t *BluezBlock) OnEvent(e *I3barClickEvent, ctx context.Context) {
+	if t.dbus == nil {
+		return
+	}
+	var call *dbus.Call
+	switch e.Button {
+	case ButtonMiddle:
+		if t.adapter == nil {
+			return
+		}
+		call = t.dbus.Object(bluezDbusDest, t.adapter.path).CallWithContext(
+			ctx, dbusPropertiesIface+".Set", 0,
+			bluezAdapterIface, "Powered", dbus.MakeVariant(!t.adapter.powered),
+		)
+	case ButtonLeft:
+		d := t.findDevice(e.Instance)
+		if d == nil {
+			return
+		}
+		method := bluezDeviceIface + ".Connect"
+		if d.connected {
+			method = bluezDeviceIface + ".Disconnect"
+		}
+		call = t.dbus.Object(bluezDbusDest, d.path).CallWithContext(ctx, method, 0)
+	default:
+		return
+	}
+	if call.Err != nil {
+		Log.Print(call.Err)
+	}
+}
+
+func (b *BluezBlock) adapterArgs() formatting.NamedArgs {
+	count := 0
 	for _, d := range b.devices {
 		if d.connected {
-			args := formatting.NamedArgs{
-				"icon":  b.Icons[d.icon],
-				"name":  d.name,
-				"alias": d.alias,
+			count++
+		}
+	}
+	args := formatting.NamedArgs{"count": count}
+	if b.adapter != nil {
+		state := b.adapter.state()
+		args["state"] = state
+		args["icon"] = b.AdapterIcons[state]
+		args["name"] = b.adapter.alias
+	}
+	return args
+}
+
+func (b *BluezBlock) renderDevice(cfg *AppConfig, d *bluezDevice) I3barBlock {
+	state := "disconnected"
+	if d.connected {
+		state = "connected"
+	}
+	args := formatting.NamedArgs{
+		"icon":  b.Icons[d.icon],
+		"name":  d.name,
+		"alias": d.alias,
+		"mac":   d.address,
+		"state": state,
+	}
+	block := I3barBlock{Instance: d.address}
+	if d.battery >= 0 {
+		args["battery"] = d.battery
+		if d.battery < b.LowBattery && cfg.Theme != nil {
+			block.Color = cfg.Theme.HSVColor(PercentageToHue(d.battery)).String()
+		}
+	}
+	block.FullText = b.DeviceFormat.Expand(args)
+	return block
+}
+
+func (b *BluezBlock) Render(cfg *AppConfig) []I3barBlock {
+	if b.AdapterFormat == nil {
+		labels := []string{}
+		for _, d := range b.sortedDevices() {
+			if d.connected {
+				labels = append(labels, b.renderDevice(cfg, d).FullText)
 			}
-			labels = append(labels, b.DeviceFormat.Expand(args))
+		}
+		args := b.adapterArgs()
+		args["devices"] = strings.Join(labels, " ")
+		return []I3barBlock{{FullText: b.Format.Expand(args)}}
+	}
+	blocks := []I3barBlock{}
+	if b.adapter != nil {
+		blocks = append(blocks, I3barBlock{
+			FullText: b.AdapterFormat.Expand(b.adapterArgs()),
+			Instance: bluezAdapterInstance,
+		})
+	}
+	for _, d := range b.sortedDevices() {
+		if d.connected || b.isFavourite(d.address) {
+			blocks = append(blocks, b.renderDevice(cfg, d))
 		}
 	}
-	return []I3barBlock{{
-		FullText: b.Format.Expand(formatting.NamedArgs{
-			"devices": strings.Join(labels, " "),
-		}),
-	}}
+	return blocks
 }
 
 func init() {
@@ -0,0 +1,87 @@
+package blocks
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	. "github.com/kraftwerk28/gost/core"
+)
+
+func newTestBluezBlock(devices ...*bluezDevice) *BluezBlock {
+	b := NewBluezBlock().(*BluezBlock)
+	b.DeviceFormat = NewConfigFormatFromString("{alias}")
+	b.devices = map[dbus.ObjectPath]*bluezDevice{}
+	for _, d := range devices {
+		d.path = dbus.ObjectPath("/org/bluez/hci0/dev_" + d.address)
+		b.devices[d.path] = d
+	}
+	return b
+}
+
+func TestBluezDeviceOrder(t *testing.T) {
+	b := newTestBluezBlock(
+		&bluezDevice{alias: "mouse", address: "AA", connected: true, battery: -1},
+		&bluezDevice{alias: "Headset", address: "BB", connected: true, battery: -1},
+		&bluezDevice{alias: "phone", address: "CC", battery: -1},
+		&bluezDevice{alias: "keyboard", address: "DD", battery: -1},
+	)
+	b.Favourites = []string{"dd", "CC"}
+	exp := []string{"DD", "CC", "BB", "AA"}
+	devices := b.sortedDevices()
+	for i, d := range devices {
+		if d.address != exp[i] {
+			t.Errorf("Expected %s at %d, got %s", exp[i], i, d.address)
+		}
+	}
+}
+
+func TestBluezRender(t *testing.T) {
+	cases := []struct {
+		adapterFormat *ConfigFormat
+		exp           []string
+	}{
+		// Disconnected favourites are shown only as separate blocks
+		{nil, []string{"Headset mouse"}},
+		{NewConfigFormatFromString("{name}"), []string{"hci0", "phone", "Headset", "mouse"}},
+	}
+	for _, c := range cases {
+		b := newTestBluezBlock(
+			&bluezDevice{alias: "mouse", address: "AA", connected: true, battery: -1},
+			&bluezDevice{alias: "Headset", address: "BB", connected: true, battery: 90},
+			&bluezDevice{alias: "phone", address: "CC", battery: -1},
+			&bluezDevice{alias: "speaker", address: "DD", battery: -1},
+		)
+		b.adapter = &bluezAdapter{alias: "hci0", powered: true}
+		b.Favourites = []string{"CC"}
+		b.AdapterFormat = c.adapterFormat
+		blocks := b.Render(&AppConfig{})
+		if len(blocks) != len(c.exp) {
+			t.Errorf("Expected %d blocks, got %+v", len(c.exp), blocks)
+			continue
+		}
+		for i, block := range blocks {
+			if block.FullText != c.exp[i] {
+				t.Errorf(`Expected "%s", got "%s"`, c.exp[i], block.FullText)
+			}
+		}
+	}
+}
+
+func TestBluezMacFilter(t *testing.T) {
+	b := NewBluezBlock().(*BluezBlock)
+	b.Device = "aa:bb:cc:dd:ee:ff"
+	b.ExcludeMac = []string{"11:22:33:44:55:66"}
+	cases := []struct {
+		addr     string
+		excluded bool
+	}{
+		{"AA:BB:CC:DD:EE:FF", false},
+		{"11:22:33:44:55:66", true},
+		{"00:00:00:00:00:00", true},
+	}
+	for _, c := range cases {
+		if got := b.isExcluded(c.addr); got != c.excluded {
+			t.Errorf("%s: expected excluded=%v, got %v", c.addr, c.excluded, got)
+		}
+	}
+}
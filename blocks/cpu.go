@@ -0,0 +1,106 @@
+package blocks
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kraftwerk28/gost/blocks/procfs"
+	"github.com/kraftwerk28/gost/blocks/sysfs"
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+// CPU utilisation from /proc/stat and frequency from cpufreq
+type CpuConfig struct {
+	BaseBlockletConfig `yaml:",inline"`
+	Interval           *ConfigInterval `yaml:"interval"`
+	// Placeholders: `{usage}` (percents), `{cores}` (a bar per core),
+	// `{core0}`, `{core1}` etc. (as numbered in /proc/stat),
+	// `{frequency}` (average, Hz), `{max_frequency}`. I.e. `{frequency;G*Hz}`
+	Format *ConfigFormat `yaml:"format"`
+	// Usage above which the block is colored
+	ColorThreshold int `yaml:"color_threshold"`
+}
+
+type CpuBlock struct {
+	CpuConfig
+	procRoot, sysRoot string
+	stats             []procfs.CPUStat
+	// Total followed by each core
+	usage       []float64
+	frequencies []float64
+}
+
+func NewCpuBlock() I3barBlocklet {
+	b := CpuBlock{procRoot: procfs.Root, sysRoot: sysfs.CPURoot}
+	interval := ConfigInterval(2 * time.Second)
+	b.Interval = &interval
+	b.Format = NewConfigFormatFromString("{usage:2*%}")
+	b.ColorThreshold = 50
+	return &b
+}
+
+func (t *CpuBlock) GetConfig() interface{} {
+	return &t.CpuConfig
+}
+
+func (t *CpuBlock) poll() error {
+	stats, err := procfs.ReadStat(t.procRoot)
+	if err != nil {
+		return err
+	}
+	if len(stats) == len(t.stats) {
+		t.usage = make([]float64, len(stats))
+		for i := range stats {
+			t.usage[i] = procfs.CPUUsage(t.stats[i], stats[i])
+		}
+	} else {
+		// A core went on- or offline, usage is known after the next poll
+		t.usage = nil
+	}
+	t.stats = stats
+	// Not available in virtual machines
+	t.frequencies, _ = sysfs.ReadCPUFrequencies(t.sysRoot)
+	return nil
+}
+
+func (t *CpuBlock) Run(ch UpdateChan, ctx context.Context) {
+	runPolling(ctx, ch, time.Duration(*t.Interval), t.poll)
+}
+
+func (t *CpuBlock) Render(cfg *AppConfig) []I3barBlock {
+	// Usage is known after the second sample
+	if len(t.usage) == 0 {
+		return nil
+	}
+	usage := int(t.usage[0] + 0.5)
+	args := formatting.NamedArgs{
+		"usage": usage,
+		"cores": ScaledSparkline(t.usage[1:], 100),
+	}
+	// Keyed by the kernel's CPU number, which has gaps when a core is offline
+	for i, u := range t.usage[1:] {
+		name := "core" + strings.TrimPrefix(t.stats[i+1].Name, "cpu")
+		args[name] = int(u + 0.5)
+	}
+	if len(t.frequencies) > 0 {
+		sum, max := 0.0, 0.0
+		for _, f := range t.frequencies {
+			sum += f
+			if f > max {
+				max = f
+			}
+		}
+		args["frequency"] = sum / float64(len(t.frequencies))
+		args["max_frequency"] = max
+	}
+	return []I3barBlock{{
+		FullText: t.Format.Expand(args),
+		Color:    usageColor(cfg, t.usage[0], t.ColorThreshold),
+	}}
+}
+
+func init() {
+	RegisterBlocklet("cpu", NewCpuBlock)
+}
@@ -0,0 +1,102 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"time"
+
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+// Disk space of mount points, one block per mount point. Sizes are in
+// bytes, i.e. `{free;G*B}`
+type DiskConfig struct {
+	BaseBlockletConfig `yaml:",inline"`
+	Interval           *ConfigInterval `yaml:"interval"`
+	MountPoints        []string        `yaml:"mount_points"`
+	// Placeholders: `{mount}`, `{total}`, `{used}`, `{free}` (available
+	// to unprivileged users), `{used_percentage}`, `{free_percentage}`
+	Format *ConfigFormat `yaml:"format"`
+	// Used space percentage above which the block is colored
+	ColorThreshold int `yaml:"color_threshold"`
+}
+
+type diskUsage struct {
+	mount             string
+	total, used, free uint64
+}
+
+// Same as in df, reserved blocks are not counted
+func (d *diskUsage) usedPercentage() int {
+	return percentage(d.used, d.used+d.free)
+}
+
+type DiskBlock struct {
+	DiskConfig
+	usage []diskUsage
+}
+
+func NewDiskBlock() I3barBlocklet {
+	b := DiskBlock{}
+	interval := ConfigInterval(30 * time.Second)
+	b.Interval = &interval
+	b.MountPoints = []string{"/"}
+	b.Format = NewConfigFormatFromString("{mount} {free;G*B}")
+	b.ColorThreshold = 80
+	return &b
+}
+
+func (t *DiskBlock) GetConfig() interface{} {
+	return &t.DiskConfig
+}
+
+func (t *DiskBlock) poll() error {
+	usage := make([]diskUsage, 0, len(t.MountPoints))
+	for _, m := range t.MountPoints {
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(m, &st); err != nil {
+			// Removable media may be unmounted
+			Log.Print(fmt.Errorf("statfs %s: %w", m, err))
+			continue
+		}
+		bsize := uint64(st.Bsize)
+		usage = append(usage, diskUsage{
+			mount: m,
+			total: st.Blocks * bsize,
+			used:  (st.Blocks - st.Bfree) * bsize,
+			free:  st.Bavail * bsize,
+		})
+	}
+	t.usage = usage
+	return nil
+}
+
+func (t *DiskBlock) Run(ch UpdateChan, ctx context.Context) {
+	runPolling(ctx, ch, time.Duration(*t.Interval), t.poll)
+}
+
+func (t *DiskBlock) Render(cfg *AppConfig) []I3barBlock {
+	blocks := make([]I3barBlock, 0, len(t.usage))
+	for _, d := range t.usage {
+		used := d.usedPercentage()
+		blocks = append(blocks, I3barBlock{
+			FullText: t.Format.Expand(formatting.NamedArgs{
+				"mount":           d.mount,
+				"total":           d.total,
+				"used":            d.used,
+				"free":            d.free,
+				"used_percentage": used,
+				"free_percentage": 100 - used,
+			}),
+			Instance: d.mount,
+			Color:    usageColor(cfg, float64(used), t.ColorThreshold),
+		})
+	}
+	return blocks
+}
+
+func init() {
+	RegisterBlocklet("disk", NewDiskBlock)
+}
@@ -37,6 +37,10 @@ func (s *IpcClient) Recv() (messageType IpcMsgType, out interface{}, err error)
 			out = &InputChange{}
 		case IpcEventTypeWindow:
 			out = &WindowChange{}
+		case IpcEventTypeMode:
+			out = &ModeChange{}
+		case IpcEventTypeOutput:
+			out = &OutputChange{}
 		}
 	} else {
 		// This is a response to a call
@@ -47,6 +51,14 @@ func (s *IpcClient) Recv() (messageType IpcMsgType, out interface{}, err error)
 			out = &IpcResult{}
 		case IpcMsgTypeCommand:
 			out = &IpcCmdResult{}
+		case IpcMsgTypeGetBindingState:
+			out = &IpcBindingState{}
+		case IpcMsgTypeGetConfig:
+			out = &IpcConfig{}
+		case IpcMsgTypeGetTree:
+			out = &IpcNode{}
+		case IpcMsgTypeGetOutputs:
+			out = &[]IpcOutput{}
 		}
 	}
 	if out == nil {
@@ -3,12 +3,18 @@ package ipc
 type IpcMsgType uint32
 
 const (
-	IpcMsgTypeCommand   IpcMsgType = 0
-	IpcMsgTypeSubscribe IpcMsgType = 2
-	IpcMsgTypeGetInputs IpcMsgType = 100
-	IpcEventTypeInput   IpcMsgType = 0x15
-	IpcEventTypeWindow  IpcMsgType = 0x3
-	IpcMsgTypeInvalid   IpcMsgType = 0x7fffffff
+	IpcMsgTypeCommand         IpcMsgType = 0
+	IpcMsgTypeSubscribe       IpcMsgType = 2
+	IpcMsgTypeGetOutputs      IpcMsgType = 3
+	IpcMsgTypeGetTree         IpcMsgType = 4
+	IpcMsgTypeGetConfig       IpcMsgType = 9
+	IpcMsgTypeGetBindingState IpcMsgType = 12
+	IpcMsgTypeGetInputs       IpcMsgType = 100
+	IpcEventTypeOutput        IpcMsgType = 0x1
+	IpcEventTypeMode          IpcMsgType = 0x2
+	IpcEventTypeInput         IpcMsgType = 0x15
+	IpcEventTypeWindow        IpcMsgType = 0x3
+	IpcMsgTypeInvalid         IpcMsgType = 0x7fffffff
 )
 
 type IpcHeader struct {
@@ -19,17 +25,104 @@ type IpcHeader struct {
 
 var ipcMagic = [6]byte{'i', '3', '-', 'i', 'p', 'c'}
 
+type IpcLibinput struct {
+	SendEvents    string `json:"send_events"`
+	Tap           string `json:"tap"`
+	NaturalScroll string `json:"natural_scroll"`
+	AccelProfile  string `json:"accel_profile"`
+	ScrollMethod  string `json:"scroll_method"`
+	Dwt           string `json:"dwt"`
+}
+
 type IpcInputDevice struct {
-	Identifier           string   `json:"identifier"`
-	Type                 string   `json:"type"`
-	XkbActiveLayoutName  string   `json:"xkb_active_layout_name"`
-	XkbLayoutNames       []string `json:"xkb_layout_names"`
-	XkbActiveLayoutIndex int      `json:"xkb_active_layout_index"`
+	Identifier           string       `json:"identifier"`
+	Name                 string       `json:"name"`
+	Type                 string       `json:"type"`
+	XkbActiveLayoutName  string       `json:"xkb_active_layout_name"`
+	XkbLayoutNames       []string     `json:"xkb_layout_names"`
+	XkbActiveLayoutIndex int          `json:"xkb_active_layout_index"`
+	Libinput             *IpcLibinput `json:"libinput"`
 }
 
 type IpcContainer struct {
-	Name  string `json:"name"`
-	AppId string `json:"app_id"`
+	Id     int64  `json:"id"`
+	Name   string `json:"name"`
+	AppId  string `json:"app_id"`
+	Urgent bool   `json:"urgent"`
+}
+
+// A node of the tree returned by GET_TREE
+type IpcNode struct {
+	IpcContainer
+	Type          string    `json:"type"`
+	Focused       bool      `json:"focused"`
+	Nodes         []IpcNode `json:"nodes"`
+	FloatingNodes []IpcNode `json:"floating_nodes"`
+}
+
+// Calls `f` for each window (i.e. a container without children) in the tree
+func (n *IpcNode) Windows(f func(*IpcNode)) {
+	if len(n.Nodes) == 0 && len(n.FloatingNodes) == 0 {
+		if n.Type == "con" || n.Type == "floating_con" {
+			f(n)
+		}
+		return
+	}
+	for i := range n.Nodes {
+		n.Nodes[i].Windows(f)
+	}
+	for i := range n.FloatingNodes {
+		n.FloatingNodes[i].Windows(f)
+	}
+}
+
+// Depth-first search for the node satisfying `pred`
+func (n *IpcNode) Find(pred func(*IpcNode) bool) *IpcNode {
+	if pred(n) {
+		return n
+	}
+	for i := range n.Nodes {
+		if r := n.Nodes[i].Find(pred); r != nil {
+			return r
+		}
+	}
+	for i := range n.FloatingNodes {
+		if r := n.FloatingNodes[i].Find(pred); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+type IpcOutputMode struct {
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+	Refresh int `json:"refresh"`
+}
+
+type IpcOutput struct {
+	Name        string        `json:"name"`
+	Make        string        `json:"make"`
+	Model       string        `json:"model"`
+	Active      bool          `json:"active"`
+	Dpms        bool          `json:"dpms"`
+	Power       *bool         `json:"power"`
+	Focused     bool          `json:"focused"`
+	Scale       float64       `json:"scale"`
+	CurrentMode IpcOutputMode `json:"current_mode"`
+}
+
+// Whether the output is powered on. `power` replaces deprecated `dpms`
+// since sway 1.8
+func (o *IpcOutput) IsPowered() bool {
+	if o.Power != nil {
+		return *o.Power
+	}
+	return o.Dpms
+}
+
+type OutputChange struct {
+	Change string `json:"change"`
 }
 
 type InputChange struct {
@@ -42,6 +135,19 @@ type WindowChange struct {
 	Container IpcContainer `json:"container"`
 }
 
+type ModeChange struct {
+	Change      string `json:"change"`
+	PangoMarkup bool   `json:"pango_markup"`
+}
+
+type IpcBindingState struct {
+	Name string `json:"name"`
+}
+
+type IpcConfig struct {
+	Config string `json:"config"`
+}
+
 type IpcResult struct {
 	Success bool `json:"success"`
 }
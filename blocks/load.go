@@ -0,0 +1,76 @@
+package blocks
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/kraftwerk28/gost/blocks/procfs"
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+// System load average from /proc/loadavg
+type LoadConfig struct {
+	BaseBlockletConfig `yaml:",inline"`
+	Interval           *ConfigInterval `yaml:"interval"`
+	// Placeholders: `{load1}`, `{load5}`, `{load15}`, `{running}`, `{tasks}`
+	Format *ConfigFormat `yaml:"format"`
+	// Percentage of 1-minute load per CPU core above which the block is
+	// colored
+	ColorThreshold int `yaml:"color_threshold"`
+}
+
+type LoadBlock struct {
+	LoadConfig
+	procRoot string
+	loadavg  *procfs.Loadavg
+}
+
+func NewLoadBlock() I3barBlocklet {
+	b := LoadBlock{procRoot: procfs.Root}
+	interval := ConfigInterval(5 * time.Second)
+	b.Interval = &interval
+	b.Format = NewConfigFormatFromString("{load1}")
+	b.ColorThreshold = 50
+	return &b
+}
+
+func (t *LoadBlock) GetConfig() interface{} {
+	return &t.LoadConfig
+}
+
+func (t *LoadBlock) poll() (err error) {
+	t.loadavg, err = procfs.ReadLoadavg(t.procRoot)
+	return
+}
+
+func (t *LoadBlock) Run(ch UpdateChan, ctx context.Context) {
+	runPolling(ctx, ch, time.Duration(*t.Interval), t.poll)
+}
+
+func (t *LoadBlock) Render(cfg *AppConfig) []I3barBlock {
+	l := t.loadavg
+	if l == nil {
+		return nil
+	}
+	formatLoad := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	perCore := l.Load1 / float64(runtime.NumCPU()) * 100
+	return []I3barBlock{{
+		FullText: t.Format.Expand(formatting.NamedArgs{
+			"load1":   formatLoad(l.Load1),
+			"load5":   formatLoad(l.Load5),
+			"load15":  formatLoad(l.Load15),
+			"running": l.Running,
+			"tasks":   l.Total,
+		}),
+		Color: usageColor(cfg, perCore, t.ColorThreshold),
+	}}
+}
+
+func init() {
+	RegisterBlocklet("load", NewLoadBlock)
+}
@@ -0,0 +1,101 @@
+package blocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/kraftwerk28/gost/blocks/procfs"
+	"github.com/kraftwerk28/gost/blocks/sysfs"
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+// Memory and swap usage from /proc/meminfo. Sizes are in bytes, i.e.
+// `{used;G*B}`
+type MemoryConfig struct {
+	BaseBlockletConfig `yaml:",inline"`
+	Interval           *ConfigInterval `yaml:"interval"`
+	// Placeholders: `{total}`, `{used}`, `{available}`, `{free}`,
+	// `{buffers}`, `{cached}`, `{used_percentage}`, `{swap_total}`,
+	// `{swap_used}`, `{swap_percentage}`, `{zram}` (memory taken by zram
+	// devices), `{zram_data}` (uncompressed data stored in them)
+	Format *ConfigFormat `yaml:"format"`
+	// Used memory percentage above which the block is colored
+	ColorThreshold int `yaml:"color_threshold"`
+}
+
+type MemoryBlock struct {
+	MemoryConfig
+	procRoot, sysRoot string
+	meminfo           *procfs.Meminfo
+	zram              []sysfs.Zram
+}
+
+func NewMemoryBlock() I3barBlocklet {
+	b := MemoryBlock{procRoot: procfs.Root, sysRoot: sysfs.BlockRoot}
+	interval := ConfigInterval(5 * time.Second)
+	b.Interval = &interval
+	b.Format = NewConfigFormatFromString("{used;G*B}")
+	b.ColorThreshold = 70
+	return &b
+}
+
+func (t *MemoryBlock) GetConfig() interface{} {
+	return &t.MemoryConfig
+}
+
+func (t *MemoryBlock) poll() (err error) {
+	if t.meminfo, err = procfs.ReadMeminfo(t.procRoot); err != nil {
+		return
+	}
+	t.zram, _ = sysfs.ReadZram(t.sysRoot)
+	return
+}
+
+func (t *MemoryBlock) Run(ch UpdateChan, ctx context.Context) {
+	runPolling(ctx, ch, time.Duration(*t.Interval), t.poll)
+}
+
+func percentage(part, total uint64) int {
+	if total == 0 {
+		return 0
+	}
+	return int(float64(part)/float64(total)*100 + 0.5)
+}
+
+func (t *MemoryBlock) Render(cfg *AppConfig) []I3barBlock {
+	m := t.meminfo
+	if m == nil {
+		return nil
+	}
+	usedPercentage := percentage(m.Used(), m.Total)
+	args := formatting.NamedArgs{
+		"total":           m.Total,
+		"used":            m.Used(),
+		"available":       m.Available,
+		"free":            m.Free,
+		"buffers":         m.Buffers,
+		"cached":          m.Cached,
+		"used_percentage": usedPercentage,
+		"swap_total":      m.SwapTotal,
+		"swap_used":       m.SwapUsed(),
+		"swap_percentage": percentage(m.SwapUsed(), m.SwapTotal),
+	}
+	if len(t.zram) > 0 {
+		var used, data uint64
+		for _, z := range t.zram {
+			used += z.MemUsedTotal
+			data += z.OrigDataSize
+		}
+		args["zram"] = used
+		args["zram_data"] = data
+	}
+	return []I3barBlock{{
+		FullText: t.Format.Expand(args),
+		Color:    usageColor(cfg, float64(usedPercentage), t.ColorThreshold),
+	}}
+}
+
+func init() {
+	RegisterBlocklet("memory", NewMemoryBlock)
+}
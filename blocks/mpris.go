@@ -2,17 +2,52 @@ package blocks
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/godbus/dbus/v5"
 	. "github.com/kraftwerk28/gost/core"
 	"github.com/kraftwerk28/gost/core/formatting"
 )
 
+const (
+	mprisActionPlayPause    = "play_pause"
+	mprisActionNext         = "next"
+	mprisActionPrevious     = "previous"
+	mprisActionStop         = "stop"
+	mprisActionSeekForward  = "seek_forward"
+	mprisActionSeekBackward = "seek_backward"
+	mprisActionVolumeUp     = "volume_up"
+	mprisActionVolumeDown   = "volume_down"
+)
+
+// Displays media players, one block per player. The most recently playing
+// player goes first.
+// Available actions: `play_pause`, `next`, `previous`, `stop`,
+// `seek_forward`, `seek_backward`, `volume_up`, `volume_down`
 type MprisBlockConfig struct {
 	Icons        map[playbackStatus]string `yaml:"icons"`
 	PlayerFormat *ConfigFormat             `yaml:"player_format"`
 	Separator    string                    `yaml:"separator"`
+	// Glob patterns of player bus names, with or without
+	// `org.mpris.MediaPlayer2.` prefix, i.e. `firefox.*`. If set, only
+	// matching players are shown
+	Players []string `yaml:"players"`
+	// Glob patterns of player bus names to hide
+	IgnorePlayers []string `yaml:"ignore_players"`
+	// Show only the most recently playing player
+	ActiveOnly bool `yaml:"active_only"`
+	// Seek step in seconds
+	SeekStep int `yaml:"seek_step"`
+	// Volume step in percents
+	VolumeStep int                 `yaml:"volume_step"`
+	Actions    ConfigButtonActions `yaml:"actions"`
+	// Width of `{progress}` bar in characters
+	ProgressWidth int `yaml:"progress_width"`
 }
 
 type playbackStatus string
@@ -26,20 +61,95 @@ const (
 type MprisPlayer struct {
 	dbusName       string
 	nameOwner      string
+	identity       string
 	metadata       map[string]dbus.Variant
 	playbackStatus playbackStatus
+	// Position reported by the player at `positionTime`, in microseconds
+	position     int64
+	positionTime time.Time
+	rate         float64
+}
+
+// Extrapolates the last known position, so it doesn't have to be polled
+func (p *MprisPlayer) currentPosition() time.Duration {
+	pos := time.Duration(p.position) * time.Microsecond
+	if p.playbackStatus == playbackStatusPlaying {
+		pos += time.Duration(float64(time.Since(p.positionTime)) * p.rate)
+	}
+	if length := p.length(); length > 0 && pos > length {
+		pos = length
+	}
+	return pos
+}
+
+func (p *MprisPlayer) length() time.Duration {
+	return time.Duration(p.metadataInt("mpris:length")) * time.Microsecond
+}
+
+// Metadata values are strings or lists of strings, i.e. `xesam:artist`
+func (p *MprisPlayer) metadataString(key string) string {
+	switch v := p.metadata[key].Value().(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ", ")
+	case dbus.ObjectPath:
+		return string(v)
+	}
+	return ""
+}
+
+// Players are inconsistent about integer types, i.e. `mpris:length`
+func (p *MprisPlayer) metadataInt(key string) int64 {
+	switch v := p.metadata[key].Value().(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+func formatMediaTime(d time.Duration) string {
+	s := int(d.Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
 }
 
 type MprisBlock struct {
 	MprisBlockConfig
 	dbus    *dbus.Conn
 	players []MprisPlayer
+	// When each player was playing last time, by bus name
+	lastPlaying map[string]time.Time
+	// Set by the Run loop while any player is playing, read by IsActive
+	playing int32
 }
 
 func NewMprisBlock() I3barBlocklet {
 	b := MprisBlock{}
 	b.PlayerFormat = NewConfigFormatFromString("{icon$}{title^10}")
 	b.Icons = make(map[playbackStatus]string)
+	b.IgnorePlayers = []string{"playerctld"}
+	b.SeekStep = 5
+	b.VolumeStep = 5
+	b.Actions = ConfigButtonActions{
+		"left":        mprisActionPlayPause,
+		"middle":      mprisActionPrevious,
+		"right":       mprisActionNext,
+		"scroll_up":   mprisActionSeekForward,
+		"scroll_down": mprisActionSeekBackward,
+	}
+	b.ProgressWidth = 10
+	b.lastPlaying = map[string]time.Time{}
 	return &b
 }
 
@@ -48,17 +158,76 @@ func (s *MprisBlock) GetConfig() interface{} {
 }
 
 const mprisPath dbus.ObjectPath = "/org/mpris/MediaPlayer2"
+const mprisBusNamePrefix = "org.mpris.MediaPlayer2."
+const mprisIface = "org.mpris.MediaPlayer2"
 const mprisPlayerIface = "org.mpris.MediaPlayer2.Player"
 
+// Position isn't announced with PropertiesChanged, so it is fetched on
+// playback changes and on Seeked signal
+func (b *MprisBlock) syncPosition(p *MprisPlayer) {
+	obj := b.dbus.Object(p.dbusName, mprisPath)
+	p.rate = 1
+	obj.Call(dbusGetProperty, 0, mprisPlayerIface, "Rate").Store(&p.rate)
+	p.position = 0
+	obj.Call(dbusGetProperty, 0, mprisPlayerIface, "Position").Store(&p.position)
+	p.positionTime = time.Now()
+}
+
+func (b *MprisBlock) anyPlaying() bool {
+	for i := range b.players {
+		if b.players[i].playbackStatus == playbackStatusPlaying {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *MprisBlock) playerAllowed(name string) bool {
+	short := strings.TrimPrefix(name, mprisBusNamePrefix)
+	if len(b.Players) > 0 && !matchesAnyPattern(b.Players, name, short) {
+		return false
+	}
+	return !matchesAnyPattern(b.IgnorePlayers, name, short)
+}
+
+func (b *MprisBlock) touchPlayer(p *MprisPlayer) {
+	if p.playbackStatus == playbackStatusPlaying {
+		b.lastPlaying[p.dbusName] = time.Now()
+	}
+}
+
+// Puts playing players first, then the ones which were playing recently
+func (b *MprisBlock) sortPlayers() {
+	sort.SliceStable(b.players, func(i, j int) bool {
+		pi, pj := &b.players[i], &b.players[j]
+		playingI := pi.playbackStatus == playbackStatusPlaying
+		playingJ := pj.playbackStatus == playbackStatusPlaying
+		if playingI != playingJ {
+			return playingI
+		}
+		return b.lastPlaying[pi.dbusName].After(b.lastPlaying[pj.dbusName])
+	})
+}
+
+func (b *MprisBlock) findPlayer(name string) *MprisPlayer {
+	for i := range b.players {
+		p := &b.players[i]
+		if p.dbusName == name || p.nameOwner == name {
+			return p
+		}
+	}
+	return nil
+}
+
 func (b *MprisBlock) fetchPlayers() (err error) {
 	var names []string
 	if err = b.dbus.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
 		return
 	}
+	sort.Strings(names)
 	b.players = make([]MprisPlayer, 0)
 	for _, name := range names {
-		if strings.Contains(name, "org.mpris.MediaPlayer2") &&
-			!strings.Contains(name, "playerctld") {
+		if strings.HasPrefix(name, mprisBusNamePrefix) && b.playerAllowed(name) {
 			p := b.dbus.Object(name, "/org/mpris/MediaPlayer2")
 			player := MprisPlayer{}
 			player.dbusName = name
@@ -67,9 +236,13 @@ func (b *MprisBlock) fetchPlayers() (err error) {
 			).Store(&player.nameOwner)
 			p.Call(dbusGetProperty, 0, mprisPlayerIface, "PlaybackStatus").Store(&player.playbackStatus)
 			p.Call(dbusGetProperty, 0, mprisPlayerIface, "Metadata").Store(&player.metadata)
+			p.Call(dbusGetProperty, 0, mprisIface, "Identity").Store(&player.identity)
+			b.syncPosition(&player)
+			b.touchPlayer(&player)
 			b.players = append(b.players, player)
 		}
 	}
+	b.sortPlayers()
 	return
 }
 
@@ -103,10 +276,19 @@ func (b *MprisBlock) Run(ch UpdateChan, ctx context.Context) {
 		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
 		dbus.WithMatchMember("PropertiesChanged"),
 	)
-	err = b.dbus.AddMatchSignal(
-		dbus.WithMatchObjectPath(dbusObjectPath),
-		dbus.WithMatchInterface("org.freedesktop.DBus"),
-	)
+	if err == nil {
+		err = b.dbus.AddMatchSignal(
+			dbus.WithMatchObjectPath(mprisPath),
+			dbus.WithMatchInterface(mprisPlayerIface),
+			dbus.WithMatchMember("Seeked"),
+		)
+	}
+	if err == nil {
+		err = b.dbus.AddMatchSignal(
+			dbus.WithMatchObjectPath(dbusObjectPath),
+			dbus.WithMatchInterface("org.freedesktop.DBus"),
+		)
+	}
 
 	if err != nil {
 		Log.Print(err)
@@ -116,6 +298,32 @@ func (b *MprisBlock) Run(ch UpdateChan, ctx context.Context) {
 	c := make(chan *dbus.Signal)
 	b.dbus.Signal(c)
 
+	// Ticks every second while something is playing, to update position
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	updateTicker := func() {
+		playing := b.anyPlaying()
+		var active int32
+		if playing {
+			active = 1
+		}
+		atomic.StoreInt32(&b.playing, active)
+		if playing && ticker == nil {
+			ticker = time.NewTicker(time.Second)
+			tick = ticker.C
+		} else if !playing && ticker != nil {
+			ticker.Stop()
+			ticker, tick = nil, nil
+		}
+	}
+	updateTicker()
+	defer func() {
+		atomic.StoreInt32(&b.playing, 0)
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
 	for {
 		select {
 		case sig := <-c:
@@ -124,31 +332,49 @@ func (b *MprisBlock) Run(ch UpdateChan, ctx context.Context) {
 				b.fetchPlayers()
 				ch.SendUpdate()
 			case mprisPath:
-				var player *MprisPlayer
-				shouldUpdate := false
-				for i := range b.players {
-					player = &b.players[i]
-					if player.dbusName == sig.Sender ||
-						player.nameOwner == sig.Sender {
-						break
+				player := b.findPlayer(sig.Sender)
+				if player == nil {
+					break
+				}
+				if sig.Name == mprisPlayerIface+".Seeked" {
+					if len(sig.Body) > 0 {
+						if pos, ok := sig.Body[0].(int64); ok {
+							player.position = pos
+							player.positionTime = time.Now()
+						}
 					}
+					ch.SendUpdate()
+					break
 				}
-				if player == nil {
+				if len(sig.Body) < 2 {
+					break
+				}
+				chProps, ok := sig.Body[1].(map[string]dbus.Variant)
+				if !ok {
 					break
 				}
-				chProps := sig.Body[1].(map[string]dbus.Variant)
+				shouldUpdate := false
 				if p, ok := chProps["PlaybackStatus"]; ok {
 					p.Store(&player.playbackStatus)
+					b.touchPlayer(player)
 					shouldUpdate = true
 				}
 				if p, ok := chProps["Metadata"]; ok {
 					p.Store(&player.metadata)
 					shouldUpdate = true
 				}
+				if _, ok := chProps["Rate"]; ok {
+					shouldUpdate = true
+				}
 				if shouldUpdate {
+					b.syncPosition(player)
+					b.sortPlayers()
 					ch.SendUpdate()
 				}
 			}
+			updateTicker()
+		case <-tick:
+			ch.SendUpdate()
 		case <-ctx.Done():
 			return
 		}
@@ -156,24 +382,93 @@ func (b *MprisBlock) Run(ch UpdateChan, ctx context.Context) {
 }
 
 func (t *MprisBlock) OnEvent(e *I3barClickEvent, ctx context.Context) {
+	if t.dbus == nil || len(t.players) == 0 {
+		return
+	}
+	player := t.findPlayer(e.Instance)
+	if player == nil {
+		player = &t.players[0]
+	}
+	obj := t.dbus.Object(player.dbusName, mprisPath)
+	var call *dbus.Call
+	switch t.Actions.Get(e.Button) {
+	case mprisActionPlayPause:
+		call = obj.CallWithContext(ctx, mprisPlayerIface+".PlayPause", 0)
+	case mprisActionNext:
+		call = obj.CallWithContext(ctx, mprisPlayerIface+".Next", 0)
+	case mprisActionPrevious:
+		call = obj.CallWithContext(ctx, mprisPlayerIface+".Previous", 0)
+	case mprisActionStop:
+		call = obj.CallWithContext(ctx, mprisPlayerIface+".Stop", 0)
+	case mprisActionSeekForward, mprisActionSeekBackward:
+		offset := time.Duration(t.SeekStep) * time.Second
+		if t.Actions.Get(e.Button) == mprisActionSeekBackward {
+			offset = -offset
+		}
+		call = obj.CallWithContext(
+			ctx, mprisPlayerIface+".Seek", 0, offset.Microseconds(),
+		)
+	case mprisActionVolumeUp, mprisActionVolumeDown:
+		var volume float64
+		if err := obj.CallWithContext(
+			ctx, dbusGetProperty, 0, mprisPlayerIface, "Volume",
+		).Store(&volume); err != nil {
+			Log.Print(err)
+			return
+		}
+		delta := float64(t.VolumeStep) / 100
+		if t.Actions.Get(e.Button) == mprisActionVolumeDown {
+			delta = -delta
+		}
+		volume = math.Max(0, math.Min(1, volume+delta))
+		call = obj.CallWithContext(
+			ctx, dbusPropertiesIface+".Set", 0,
+			mprisPlayerIface, "Volume", dbus.MakeVariant(volume),
+		)
+	default:
+		return
+	}
+	if call.Err != nil {
+		Log.Print(call.Err)
+	}
+}
+
+// Marquee placeholders scroll only while something is playing
+func (b *MprisBlock) IsActive() bool {
+	return atomic.LoadInt32(&b.playing) != 0
 }
 
 func (b *MprisBlock) Render(cfg *AppConfig) []I3barBlock {
-	if len(b.players) == 0 {
-		return nil
-	}
-	parts := make([]string, len(b.players))
-	for i, pl := range b.players {
-		var title string
-		if titleVar, ok := pl.metadata["xesam:title"]; ok {
-			titleVar.Store(&title)
+	players := b.players
+	if b.ActiveOnly && len(players) > 1 {
+		players = players[:1]
+	}
+	blocks := make([]I3barBlock, len(players))
+	for i := range players {
+		pl := &players[i]
+		position, length := pl.currentPosition(), pl.length()
+		var progress float64
+		if length > 0 {
+			progress = float64(position) / float64(length)
 		}
-		parts[i] = b.PlayerFormat.Expand(formatting.NamedArgs{
-			"icon":  b.Icons[pl.playbackStatus],
-			"title": title,
+		text := b.PlayerFormat.Expand(formatting.NamedArgs{
+			"icon":     b.Icons[pl.playbackStatus],
+			"title":    pl.metadataString("xesam:title"),
+			"artist":   pl.metadataString("xesam:artist"),
+			"album":    pl.metadataString("xesam:album"),
+			"track":    pl.metadataInt("xesam:trackNumber"),
+			"url":      pl.metadataString("xesam:url"),
+			"player":   pl.identity,
+			"position": formatMediaTime(position),
+			"length":   formatMediaTime(length),
+			"progress": ProgressBar(progress, b.ProgressWidth),
 		})
+		if i < len(players)-1 {
+			text += b.Separator
+		}
+		blocks[i] = I3barBlock{FullText: text, Instance: pl.dbusName}
 	}
-	return []I3barBlock{{FullText: strings.Join(parts, b.Separator)}}
+	return blocks
 }
 
 func init() {
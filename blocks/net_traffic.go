@@ -0,0 +1,138 @@
+package blocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/kraftwerk28/gost/blocks/procfs"
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+// Network throughput of an interface, sampled from /proc/net/dev. Rates are
+// in bytes per second, so engineering prefixes fit them well, i.e.
+// `{rx;K*B/s}`
+type NetTrafficConfig struct {
+	BaseBlockletConfig `yaml:",inline"`
+	// Interface name. The one of the default route is used if empty
+	Interface string          `yaml:"interface"`
+	Interval  *ConfigInterval `yaml:"interval"`
+	// Placeholders: `{interface}`, `{rx}`, `{tx}`, `{rx_total}`,
+	// `{tx_total}` (bytes since start), `{rx_graph}`, `{tx_graph}`
+	Format *ConfigFormat `yaml:"format"`
+	// Weight of the latest sample in rates, from 0 to 1. 1 disables smoothing
+	Smoothing float64 `yaml:"smoothing"`
+	// Number of samples in `{rx_graph}` and `{tx_graph}`
+	GraphWidth int `yaml:"graph_width"`
+}
+
+type netTrafficCounter struct {
+	last, first uint64
+	rate        float64
+	history     []float64
+}
+
+// Accounts a new sample of the counter, `dt` seconds after the last one
+func (c *netTrafficCounter) add(value uint64, dt, smoothing float64, width int) {
+	// The counter was reset, i.e. the interface was recreated
+	if value < c.last {
+		c.first -= c.last - value
+		c.last = value
+	}
+	rate := float64(value-c.last) / dt
+	c.rate = smoothing*rate + (1-smoothing)*c.rate
+	c.last = value
+	c.history = append(c.history, c.rate)
+	if len(c.history) > width {
+		c.history = c.history[len(c.history)-width:]
+	}
+}
+
+type NetTrafficBlock struct {
+	NetTrafficConfig
+	root       string
+	iface      string
+	lastSample time.Time
+	rx, tx     netTrafficCounter
+}
+
+func NewNetTrafficBlock() I3barBlocklet {
+	b := NetTrafficBlock{root: procfs.Root}
+	interval := ConfigInterval(2 * time.Second)
+	b.Interval = &interval
+	b.Format = NewConfigFormatFromString("↓{rx:3;K*B/s} ↑{tx:3;K*B/s}")
+	b.Smoothing = 0.5
+	b.GraphWidth = 10
+	return &b
+}
+
+func (t *NetTrafficBlock) GetConfig() interface{} {
+	return &t.NetTrafficConfig
+}
+
+func (t *NetTrafficBlock) sample() error {
+	iface := t.Interface
+	if iface == "" {
+		var err error
+		if iface, err = procfs.DefaultRouteInterface(t.root); err != nil {
+			return err
+		}
+	}
+	devices, err := procfs.ReadNetDev(t.root)
+	if err != nil {
+		return err
+	}
+	var dev *procfs.NetDev
+	for i := range devices {
+		if devices[i].Name == iface {
+			dev = &devices[i]
+			break
+		}
+	}
+	now := time.Now()
+	if dev == nil || iface != t.iface {
+		t.iface = ""
+		t.rx, t.tx = netTrafficCounter{}, netTrafficCounter{}
+		if dev != nil {
+			t.iface = iface
+			t.rx = netTrafficCounter{last: dev.RxBytes, first: dev.RxBytes}
+			t.tx = netTrafficCounter{last: dev.TxBytes, first: dev.TxBytes}
+		}
+		t.lastSample = now
+		return nil
+	}
+	smoothing := t.Smoothing
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 1
+	}
+	dt := now.Sub(t.lastSample).Seconds()
+	t.rx.add(dev.RxBytes, dt, smoothing, t.GraphWidth)
+	t.tx.add(dev.TxBytes, dt, smoothing, t.GraphWidth)
+	t.lastSample = now
+	return nil
+}
+
+func (t *NetTrafficBlock) Run(ch UpdateChan, ctx context.Context) {
+	runPolling(ctx, ch, time.Duration(*t.Interval), t.sample)
+}
+
+func (t *NetTrafficBlock) Render(cfg *AppConfig) []I3barBlock {
+	if t.iface == "" {
+		return nil
+	}
+	return []I3barBlock{{
+		FullText: t.Format.Expand(formatting.NamedArgs{
+			"interface": t.iface,
+			"rx":        t.rx.rate,
+			"tx":        t.tx.rate,
+			"rx_total":  t.rx.last - t.rx.first,
+			"tx_total":  t.tx.last - t.tx.first,
+			"rx_graph":  Sparkline(t.rx.history),
+			"tx_graph":  Sparkline(t.tx.history),
+		}),
+	}}
+}
+
+func init() {
+	RegisterBlocklet("net_traffic", NewNetTrafficBlock)
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/godbus/dbus/v5"
 	. "github.com/kraftwerk28/gost/core"
@@ -14,19 +17,72 @@ import (
 const nmDbusDest = "org.freedesktop.NetworkManager"
 const nmDbusBasePath dbus.ObjectPath = "/org/freedesktop/NetworkManager"
 
+const (
+	nmActionNextConnection   = "next_connection"
+	nmActionPrevConnection   = "prev_connection"
+	nmActionToggleWifi       = "toggle_wifi"
+	nmActionToggleNetworking = "toggle_networking"
+	nmActionToggleConnection = "toggle_connection"
+	nmActionNextWifiProfile  = "next_wifi_profile"
+	nmActionOpenPortal       = "open_portal"
+)
+
+// For how long a failed activation is shown
+const nmActivationFailedTimeout = 5 * time.Second
+
+// Available actions: `next_connection`, `prev_connection`, `toggle_wifi`,
+// `toggle_networking`, `toggle_connection`, `next_wifi_profile`,
+// `open_portal`.
+// Besides device icons, `icons` may contain `activating` and `failed` for
+// `{activation}` and a `connectivity` map for `{connectivity}`, keyed by
+// connectivity state: `none`, `portal`, `limited` or `full`. The state name
+// is shown if there's no icon for it. `colors` map sets the text color per
+// connectivity state
 type NetworkManagerBlockConfig struct {
 	BaseBlockletConfig `yaml:",inline"`
-	Format             *ConfigFormat          `yaml:"format"`
-	AccessPointFormat  *ConfigFormat          `yaml:"ap_format"`
-	PrimaryOnly        bool                   `yaml:"primary_only"`
-	Icons              map[string]interface{} `yaml:"icons"`
+	// Placeholders: `{status_icon}`, `{vpn}`, `{access_point}`,
+	// `{connection_name}`, `{type}`, `{device}`, `{ipv4}`, `{ipv6}`,
+	// `{gateway}`, `{dns}`, `{bitrate}` (Mbit/s), `{frequency}` (GHz),
+	// `{activation}`, `{connectivity}`
+	Format *ConfigFormat `yaml:"format"`
+	// Placeholders: `{strength}`, `{ssid}`, `{frequency}`
+	AccessPointFormat *ConfigFormat `yaml:"ap_format"`
+	// Show one active connection at a time, starting with the primary one.
+	// Otherwise, every active connection gets its own block
+	PrimaryOnly bool `yaml:"primary_only"`
+	// Besides device icons, `activating` and `failed` are used in
+	// `{activation}`. `connectivity` map is used in `{connectivity}`, the
+	// state name is shown if there's no icon for it
+	Icons   map[string]interface{} `yaml:"icons"`
+	Actions ConfigButtonActions    `yaml:"actions"`
+	// Name or UUID of a saved connection, i.e. VPN, which is brought up or
+	// down by `toggle_connection`
+	Connection string `yaml:"connection"`
+	// Names or UUIDs of saved Wi-Fi connections, `next_wifi_profile`
+	// activates the one after the current
+	WifiProfiles []string `yaml:"wifi_profiles"`
+	// `{activation}` while a connection brought up by an action is being
+	// activated or has failed. Placeholders: `{icon}`, `{state}`,
+	// `{connection_name}`
+	ActivationFormat *ConfigFormat `yaml:"activation_format"`
+	// Keyed by connectivity state: `none`, `portal`, `limited` or `full`
+	Colors map[string]*ConfigColor `yaml:"colors"`
+	// Opened by `open_portal`. NetworkManager's connectivity check URI is
+	// used by default, captive portals redirect it to the login page
+	PortalUrl string `yaml:"portal_url"`
 }
 
 type NmActiveConnection struct {
 	objectPath dbus.ObjectPath
-	device     NmDevice
-	ip4Config  NmIp4Config
-	isVpn      bool
+	// Connection name, i.e. SSID for wireless connections
+	id        string
+	uuid      string
+	connType  string
+	state     nmActiveConnectionState
+	device    NmDevice
+	ip4Config NmIp4Config
+	ip6Config NmIp6Config
+	isVpn     bool
 }
 
 func (c *NmActiveConnection) isWireless() bool {
@@ -38,9 +94,51 @@ func (c *NmActiveConnection) isWireless() bool {
 	}
 }
 
+// Short connection type, i.e. `wifi` instead of `802-11-wireless`
+func (c *NmActiveConnection) typeName() string {
+	switch c.connType {
+	case "802-11-wireless":
+		return "wifi"
+	case "802-3-ethernet":
+		return "ethernet"
+	default:
+		return c.connType
+	}
+}
+
+// Loopback, bridges and other virtual devices are not worth a block
+func (c *NmActiveConnection) isDisplayed() bool {
+	if c.isVpn {
+		return true
+	}
+	switch c.device.deviceType {
+	case NM_DEVICE_TYPE_ETHERNET,
+		NM_DEVICE_TYPE_WIFI,
+		NM_DEVICE_TYPE_WIREGUARD:
+		return true
+	default:
+		return false
+	}
+}
+
 func (c *NmActiveConnection) loadProps(conn *dbus.Conn) (err error) {
 	o := conn.Object(nmDbusDest, c.objectPath)
 	iface := nmDbusDest + ".Connection.Active"
+	if err = dbusGetProp(o, iface, "Id", &c.id); err != nil {
+		return
+	}
+	if err = dbusGetProp(o, iface, "Uuid", &c.uuid); err != nil {
+		return
+	}
+	if err = dbusGetProp(o, iface, "Type", &c.connType); err != nil {
+		return
+	}
+	if err = dbusGetProp(o, iface, "State", &c.state); err != nil {
+		return
+	}
+	if err = dbusGetProp(o, iface, "Vpn", &c.isVpn); err != nil {
+		return
+	}
 	var devices []dbus.ObjectPath
 	if err = dbusGetProp(o, iface, "Devices", &devices); err != nil {
 		return
@@ -58,7 +156,10 @@ func (c *NmActiveConnection) loadProps(conn *dbus.Conn) (err error) {
 	if err = c.ip4Config.loadProps(conn); err != nil {
 		return
 	}
-	if err = dbusGetProp(o, iface, "Vpn", &c.isVpn); err != nil {
+	if err = dbusGetProp(o, iface, "Ip6Config", &c.ip6Config.objectPath); err != nil {
+		return
+	}
+	if err = c.ip6Config.loadProps(conn); err != nil {
 		return
 	}
 	return
@@ -67,8 +168,10 @@ func (c *NmActiveConnection) loadProps(conn *dbus.Conn) (err error) {
 type NmDevice struct {
 	objectPath  dbus.ObjectPath
 	deviceType  nmDeviceType
+	iface       string
 	accessPoint NmAccessPoint
-	bitrate     uint32
+	// Kbit/s
+	bitrate uint32
 }
 
 func (d *NmDevice) loadProps(conn *dbus.Conn) (err error) {
@@ -76,12 +179,21 @@ func (d *NmDevice) loadProps(conn *dbus.Conn) (err error) {
 	if err = dbusGetProp(o, nmDbusDest+".Device", "DeviceType", &d.deviceType); err != nil {
 		return
 	}
+	if err = dbusGetProp(o, nmDbusDest+".Device", "Interface", &d.iface); err != nil {
+		return
+	}
+	if d.deviceType == NM_DEVICE_TYPE_ETHERNET {
+		// Reported in Mbit/s
+		var speed uint32
+		dbusGetProp(o, nmDbusDest+".Device.Wired", "Speed", &speed)
+		d.bitrate = speed * 1000
+	}
 	dbusGetProp(o, nmDbusDest+".Device.Wireless", "Bitrate", &d.bitrate)
 	dbusGetProp(
 		o, nmDbusDest+".Device.Wireless",
 		"ActiveAccessPoint", &d.accessPoint.objectPath,
 	)
-	if d.accessPoint.objectPath != "" {
+	if d.accessPoint.objectPath != "" && d.accessPoint.objectPath != "/" {
 		if err = d.accessPoint.loadProps(conn); err != nil {
 			return
 		}
@@ -92,6 +204,8 @@ func (d *NmDevice) loadProps(conn *dbus.Conn) (err error) {
 type NmIp4Config struct {
 	objectPath dbus.ObjectPath
 	ip         net.IP
+	gateway    string
+	dns        []string
 }
 
 func (c *NmIp4Config) ipString() string {
@@ -103,9 +217,30 @@ func (c *NmIp4Config) ipString() string {
 }
 
 func (c *NmIp4Config) loadProps(conn *dbus.Conn) (err error) {
+	c.ip, c.gateway, c.dns = nil, "", nil
+	// There's no IPv4 configuration
+	if c.objectPath == "/" || c.objectPath == "" {
+		return
+	}
 	o := conn.Object(nmDbusDest, c.objectPath)
+	iface := nmDbusDest + ".IP4Config"
+	// Gateway and DNS are nice to have: NameserverData is missing in older
+	// NetworkManager versions
+	if err := dbusGetProp(o, iface, "Gateway", &c.gateway); err != nil {
+		Log.Print(err)
+	}
+	var nameservers []map[string]dbus.Variant
+	if err := dbusGetProp(o, iface, "NameserverData", &nameservers); err != nil {
+		Log.Print(err)
+	}
+	for _, ns := range nameservers {
+		var addr string
+		if ns["address"].Store(&addr) == nil {
+			c.dns = append(c.dns, addr)
+		}
+	}
 	var ipv4Addresses [][]uint32
-	if err = dbusGetProp(o, nmDbusDest+".IP4Config", "Addresses", &ipv4Addresses); err != nil {
+	if err = dbusGetProp(o, iface, "Addresses", &ipv4Addresses); err != nil {
 		return
 	}
 	if len(ipv4Addresses) < 1 || len(ipv4Addresses[0]) < 1 {
@@ -116,10 +251,71 @@ func (c *NmIp4Config) loadProps(conn *dbus.Conn) (err error) {
 	return
 }
 
+type NmIp6Config struct {
+	objectPath dbus.ObjectPath
+	ip         net.IP
+	gateway    string
+	dns        []string
+}
+
+func (c *NmIp6Config) ipString() string {
+	if c.ip == nil {
+		return ""
+	}
+	return c.ip.String()
+}
+
+func (c *NmIp6Config) loadProps(conn *dbus.Conn) (err error) {
+	c.ip, c.gateway, c.dns = nil, "", nil
+	if c.objectPath == "/" || c.objectPath == "" {
+		return
+	}
+	o := conn.Object(nmDbusDest, c.objectPath)
+	iface := nmDbusDest + ".IP6Config"
+	if err = dbusGetProp(o, iface, "Gateway", &c.gateway); err != nil {
+		return
+	}
+	var nameservers [][]byte
+	if err = dbusGetProp(o, iface, "Nameservers", &nameservers); err != nil {
+		return
+	}
+	for _, ns := range nameservers {
+		c.dns = append(c.dns, net.IP(ns).String())
+	}
+	var addresses []map[string]dbus.Variant
+	if err = dbusGetProp(o, iface, "AddressData", &addresses); err != nil {
+		return
+	}
+	for _, a := range addresses {
+		var addr string
+		if a["address"].Store(&addr) != nil {
+			continue
+		}
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		// Prefer global addresses, link-local one is always there
+		if c.ip == nil || c.ip.IsLinkLocalUnicast() {
+			c.ip = ip
+		}
+	}
+	return
+}
+
 type NmAccessPoint struct {
 	objectPath dbus.ObjectPath
 	strength   int
 	ssid       string
+	// MHz
+	frequency uint32
+}
+
+func (a *NmAccessPoint) frequencyString() string {
+	if a.frequency == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", float64(a.frequency)/1000)
 }
 
 func (a *NmAccessPoint) loadProps(conn *dbus.Conn) (err error) {
@@ -133,6 +329,9 @@ func (a *NmAccessPoint) loadProps(conn *dbus.Conn) (err error) {
 		return
 	}
 	a.ssid = string(ssidByte)
+	if err = dbusGetProp(o, iface, "Frequency", &a.frequency); err != nil {
+		return
+	}
 	return
 }
 
@@ -142,14 +341,27 @@ type NetworkManagerBlock struct {
 	connections            []NmActiveConnection
 	currentConnectionIndex int
 	state                  nmState
+	connectivity           nmConnectivityState
+	connectivityCheckUri   string
+	// Connection being activated by an action. Only accessed in the Run loop,
+	// actions send new activations over `activations`
+	activation       *nmActivation
+	activations      chan *nmActivation
+	activationExpiry <-chan time.Time
+	// Actions which depend on the state, handled in the Run loop
+	runActions chan string
 }
 
 func newNetworkManagerBlock() I3barBlocklet {
 	b := NetworkManagerBlock{}
 	b.Format = NewConfigFormatFromString("{state_icon$}{percentage*%}")
 	b.AccessPointFormat = NewConfigFormatFromString("{strength:3*%$}{ssid}")
+	b.ActivationFormat = NewConfigFormatFromString("{icon}{connection_name}")
 	b.currentConnectionIndex = 0
 	b.PrimaryOnly = true
+	b.Actions = ConfigButtonActions{"left": nmActionNextConnection}
+	b.runActions = make(chan string)
+	b.activations = make(chan *nmActivation)
 	return &b
 }
 
@@ -165,33 +377,53 @@ func dbusGetProp(
 	return obj.Call(dbusGetProperty, 0, iface, propName).Store(out)
 }
 
+// Loads active connections, the primary one goes first. The currently shown
+// connection stays selected if it's still active
 func (t *NetworkManagerBlock) loadConnections() (err error) {
 	o := t.dbus.Object(nmDbusDest, nmDbusBasePath)
+	var current dbus.ObjectPath
+	if t.currentConnectionIndex < len(t.connections) {
+		current = t.connections[t.currentConnectionIndex].objectPath
+	}
 	t.connections = []NmActiveConnection{}
+	t.currentConnectionIndex = 0
 	if err = dbusGetProp(o, nmDbusDest, "State", &t.state); err != nil {
 		return
 	}
+	if err = dbusGetProp(o, nmDbusDest, "Connectivity", &t.connectivity); err != nil {
+		return
+	}
+	// Not available if connectivity checking is disabled
+	dbusGetProp(o, nmDbusDest, "ConnectivityCheckUri", &t.connectivityCheckUri)
+	var primary dbus.ObjectPath
+	if err = dbusGetProp(o, nmDbusDest, "PrimaryConnection", &primary); err != nil {
+		return
+	}
 	var connPaths []dbus.ObjectPath
-	if t.PrimaryOnly {
-		var p dbus.ObjectPath
-		if err = dbusGetProp(o, nmDbusDest, "PrimaryConnection", &p); err != nil {
-			return
+	if err = dbusGetProp(o, nmDbusDest, "ActiveConnections", &connPaths); err != nil {
+		return
+	}
+	for i, p := range connPaths {
+		if p == primary {
+			copy(connPaths[1:i+1], connPaths[:i])
+			connPaths[0] = p
+			break
 		}
-		if p == "/" {
-			return
+	}
+	for _, p := range connPaths {
+		c := NmActiveConnection{objectPath: p}
+		if err := c.loadProps(t.dbus); err != nil {
+			// The connection may be gone already
+			Log.Print(err)
+			continue
 		}
-		connPaths = []dbus.ObjectPath{p}
-	} else {
-		if err = dbusGetProp(o, nmDbusDest, "ActiveConnections", &connPaths); err != nil {
-			return
+		if !c.isDisplayed() {
+			continue
 		}
-	}
-	t.connections = make([]NmActiveConnection, len(connPaths))
-	for i := range t.connections {
-		t.connections[i].objectPath = connPaths[i]
-		if err := t.connections[i].loadProps(t.dbus); err != nil {
-			return err
+		if p == current {
+			t.currentConnectionIndex = len(t.connections)
 		}
+		t.connections = append(t.connections, c)
 	}
 	return
 }
@@ -233,64 +465,80 @@ func (t *NetworkManagerBlock) Run(ch UpdateChan, ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
+		case action := <-t.runActions:
+			if t.runAction(ctx, action) {
+				ch.SendUpdate()
+			}
+		case a := <-t.activations:
+			t.startActivation(a)
+			ch.SendUpdate()
+		case <-t.activationExpiry:
+			t.activationExpired()
+			ch.SendUpdate()
 		case sig := <-c:
-			if len(t.connections) == 0 {
+			isPropsChange := sig.Name == dbusPropertiesIface+".PropertiesChanged"
+			if a := t.activation; isPropsChange && a != nil && sig.Path == a.path {
+				if t.onActivationChanged(a, sig.Body[1].(map[string]dbus.Variant)) {
+					ch.SendUpdate()
+				}
+			}
+			if len(t.connections) == 0 || !isPropsChange {
 				t.loadConnections()
 				ch.SendUpdate()
 				break
 			}
 			changedProps := sig.Body[1].(map[string]dbus.Variant)
+			if sig.Path == nmDbusBasePath {
+				if v, ok := changedProps["Connectivity"]; ok {
+					v.Store(&t.connectivity)
+					ch.SendUpdate()
+				}
+				_, stateChanged := changedProps["State"]
+				_, primaryChanged := changedProps["PrimaryConnection"]
+				_, activeChanged := changedProps["ActiveConnections"]
+				if stateChanged || primaryChanged || activeChanged {
+					t.loadConnections()
+					ch.SendUpdate()
+				}
+				break
+			}
 			shouldUpdate := false
 			for i := range t.connections {
 				conn := &t.connections[i]
 				switch sig.Path {
-				case nmDbusBasePath:
-					if _, ok := changedProps["State"]; ok {
-						t.loadConnections()
-						ch.SendUpdate()
-					}
-					if _, ok := changedProps["PrimaryConnection"]; ok {
-						if t.PrimaryOnly {
-							t.loadConnections()
-							ch.SendUpdate()
-						}
-					}
-					if _, ok := changedProps["ActiveConnections"]; ok {
-						if !t.PrimaryOnly {
-							t.loadConnections()
-							ch.SendUpdate()
-						}
-					}
 				case conn.objectPath:
 					conn.loadProps(t.dbus)
-					ch.SendUpdate()
+					shouldUpdate = true
 				case conn.device.objectPath:
 					if st, ok := changedProps["Bitrate"]; ok {
 						st.Store(&conn.device.bitrate)
 						shouldUpdate = true
 					}
+					if _, ok := changedProps["ActiveAccessPoint"]; ok {
+						conn.device.loadProps(t.dbus)
+						shouldUpdate = true
+					}
 				case conn.device.accessPoint.objectPath:
 					if st, ok := changedProps["Strength"]; ok {
 						st.Store(&conn.device.accessPoint.strength)
 						shouldUpdate = true
 					}
 					if st, ok := changedProps["Ssid"]; ok {
-						st.Store(&conn.device.accessPoint.ssid)
+						var ssid []byte
+						st.Store(&ssid)
+						conn.device.accessPoint.ssid = string(ssid)
 						shouldUpdate = true
 					}
-					// TODO: other properties
-				case conn.ip4Config.objectPath:
-					if st, ok := changedProps["Addresses"]; ok {
-						var ipv4Addresses [][]uint32
-						st.Store(&ipv4Addresses)
-						if len(ipv4Addresses) < 1 || len(ipv4Addresses[0]) < 1 {
-							return
-						}
-						binary.BigEndian.PutUint32(
-							conn.ip4Config.ip,
-							ipv4Addresses[0][0],
-						)
+					if st, ok := changedProps["Frequency"]; ok {
+						st.Store(&conn.device.accessPoint.frequency)
+						shouldUpdate = true
 					}
+				case conn.ip4Config.objectPath:
+					conn.ip4Config.loadProps(t.dbus)
+					shouldUpdate = true
+				case conn.ip6Config.objectPath:
+					conn.ip6Config.loadProps(t.dbus)
+					shouldUpdate = true
 				}
 			}
 			if shouldUpdate {
@@ -300,39 +548,73 @@ func (t *NetworkManagerBlock) Run(ch UpdateChan, ctx context.Context) {
 	}
 }
 
-func (b *NetworkManagerBlock) Render(cfg *AppConfig) []I3barBlock {
-	var iconName string
-	var icon string
+func (b *NetworkManagerBlock) stateIconName() string {
 	switch b.state {
 	case NM_STATE_UNKNOWN, NM_STATE_ASLEEP, NM_STATE_DISCONNECTED:
-		iconName = "disconnected"
+		return "disconnected"
 	case NM_STATE_CONNECTING, NM_STATE_DISCONNECTING:
-		iconName = "connecting"
+		return "connecting"
 	case NM_STATE_CONNECTED_LOCAL, NM_STATE_CONNECTED_SITE:
-		iconName = "connected_local"
+		return "connected_local"
 	case NM_STATE_CONNECTED_GLOBAL:
-		iconName = "connected"
+		return "connected"
 	}
-	if len(b.connections) == 0 {
-		if i, ok := b.Icons[iconName].(string); ok {
-			icon = i
-		} else {
-			for _, v := range b.Icons {
-				if deviceStates, ok := v.(map[string]interface{}); ok {
-					if i, ok := deviceStates[iconName].(string); ok {
-						icon = i
-						break
-					}
-				}
-			}
+	return ""
+}
+
+func (b *NetworkManagerBlock) connectivityName() string {
+	switch b.connectivity {
+	case NM_CONNECTIVITY_NONE:
+		return "none"
+	case NM_CONNECTIVITY_PORTAL:
+		return "portal"
+	case NM_CONNECTIVITY_LIMITED:
+		return "limited"
+	case NM_CONNECTIVITY_FULL:
+		return "full"
+	default:
+		return "unknown"
+	}
+}
+
+// Sets `{connectivity}` and colors the block according to connectivity
+func (b *NetworkManagerBlock) applyConnectivity(
+	cfg *AppConfig,
+	args formatting.NamedArgs,
+	block *I3barBlock,
+) {
+	name := b.connectivityName()
+	args["connectivity"] = name
+	if icons, ok := b.Icons["connectivity"].(map[string]interface{}); ok {
+		if i, ok := icons[name].(string); ok {
+			args["connectivity"] = i
 		}
-		return []I3barBlock{{
-			FullText: b.Format.Expand(formatting.NamedArgs{
-				"status_icon": icon,
-			}),
-		}}
 	}
-	c := b.connections[b.currentConnectionIndex]
+	if c, ok := b.Colors[name]; ok && c != nil {
+		block.Color = c.String()
+		return
+	}
+	if cfg == nil || cfg.Theme == nil {
+		return
+	}
+	switch b.connectivity {
+	case NM_CONNECTIVITY_PORTAL:
+		block.Color = cfg.Theme.HSVColor(PercentageToHue(25)).String()
+	case NM_CONNECTIVITY_LIMITED:
+		block.Color = cfg.Theme.HSVColor(PercentageToHue(50)).String()
+	}
+}
+
+func (b *NetworkManagerBlock) renderConnection(
+	cfg *AppConfig,
+	c *NmActiveConnection,
+	iconName string,
+	activation string,
+) I3barBlock {
+	if c.state == NM_ACTIVE_CONNECTION_STATE_ACTIVATING {
+		iconName = "connecting"
+	}
+	var icon string
 	var iconMap map[string]interface{}
 	switch c.device.deviceType {
 	case NM_DEVICE_TYPE_ETHERNET:
@@ -343,8 +625,6 @@ func (b *NetworkManagerBlock) Render(cfg *AppConfig) []I3barBlock {
 		if m, ok := b.Icons["wifi"].(map[string]interface{}); ok {
 			iconMap = m
 		}
-	default:
-		return nil
 	}
 	if i, ok := iconMap[iconName].(string); ok {
 		icon = i
@@ -357,8 +637,9 @@ func (b *NetworkManagerBlock) Render(cfg *AppConfig) []I3barBlock {
 		if c.isWireless() {
 			ap := c.device.accessPoint
 			accessPoint = b.AccessPointFormat.Expand(formatting.NamedArgs{
-				"strength": ap.strength,
-				"ssid":     ap.ssid,
+				"strength":  ap.strength,
+				"ssid":      ap.ssid,
+				"frequency": ap.frequencyString(),
 			})
 			// TODO: refactor color applying
 			icon = fmt.Sprintf(
@@ -368,20 +649,169 @@ func (b *NetworkManagerBlock) Render(cfg *AppConfig) []I3barBlock {
 			)
 		}
 	}
+	gateway := c.ip4Config.gateway
+	if gateway == "" {
+		gateway = c.ip6Config.gateway
+	}
+	dns := append(append([]string{}, c.ip4Config.dns...), c.ip6Config.dns...)
 	args := formatting.NamedArgs{
-		"status_icon":  icon,
-		"ipv4":         c.ip4Config.ipString(),
-		"access_point": accessPoint,
+		"status_icon":     icon,
+		"ipv4":            c.ip4Config.ipString(),
+		"ipv6":            c.ip6Config.ipString(),
+		"gateway":         gateway,
+		"dns":             strings.Join(dns, " "),
+		"access_point":    accessPoint,
+		"connection_name": c.id,
+		"type":            c.typeName(),
+		"device":          c.device.iface,
+		"activation":      activation,
+	}
+	if c.device.bitrate > 0 {
+		args["bitrate"] = c.device.bitrate / 1000
+	}
+	if c.isWireless() {
+		args["frequency"] = c.device.accessPoint.frequencyString()
 	}
 	if c.isVpn {
 		if vpnIcon, ok := b.Icons["vpn"].(string); ok {
 			args["vpn"] = vpnIcon
 		}
 	}
-	return []I3barBlock{{
-		FullText: b.Format.Expand(args),
+	block := I3barBlock{
 		Markup:   MarkupPango,
-	}}
+		Instance: string(c.objectPath),
+		Urgent:   b.activation != nil && b.activation.failed,
+	}
+	b.applyConnectivity(cfg, args, &block)
+	block.FullText = b.Format.Expand(args)
+	return block
+}
+
+func (b *NetworkManagerBlock) Render(cfg *AppConfig) []I3barBlock {
+	iconName := b.stateIconName()
+	activation := b.activationText()
+	if len(b.connections) == 0 {
+		var icon string
+		if i, ok := b.Icons[iconName].(string); ok {
+			icon = i
+		} else {
+			for _, v := range b.Icons {
+				if deviceStates, ok := v.(map[string]interface{}); ok {
+					if i, ok := deviceStates[iconName].(string); ok {
+						icon = i
+						break
+					}
+				}
+			}
+		}
+		args := formatting.NamedArgs{
+			"status_icon": icon,
+			"activation":  activation,
+		}
+		block := I3barBlock{Urgent: b.activation != nil && b.activation.failed}
+		b.applyConnectivity(cfg, args, &block)
+		block.FullText = b.Format.Expand(args)
+		return []I3barBlock{block}
+	}
+	if b.PrimaryOnly {
+		index := b.currentConnectionIndex
+		if index >= len(b.connections) {
+			index = 0
+		}
+		return []I3barBlock{
+			b.renderConnection(cfg, &b.connections[index], iconName, activation),
+		}
+	}
+	blocks := make([]I3barBlock, len(b.connections))
+	for i := range b.connections {
+		blocks[i] = b.renderConnection(cfg, &b.connections[i], iconName, activation)
+	}
+	return blocks
+}
+
+// Connections are reloaded in the Run loop, so actions which read them are
+// handled there too
+func (t *NetworkManagerBlock) requestAction(ctx context.Context, action string) {
+	select {
+	case t.runActions <- action:
+	case <-ctx.Done():
+	}
+}
+
+// Reports whether the block should be re-rendered
+func (t *NetworkManagerBlock) runAction(ctx context.Context, action string) bool {
+	switch action {
+	case nmActionNextConnection:
+		return t.cycleConnection(1)
+	case nmActionPrevConnection:
+		return t.cycleConnection(-1)
+	case nmActionNextWifiProfile:
+		if name := t.nextWifiProfile(); name != "" {
+			// Activation is reported back to this loop
+			go func() {
+				if err := t.activateByName(ctx, name); err != nil {
+					Log.Print(err)
+				}
+			}()
+		}
+	case nmActionOpenPortal:
+		if err := t.openPortal(); err != nil {
+			Log.Print(err)
+		}
+	}
+	return false
+}
+
+func (t *NetworkManagerBlock) cycleConnection(dir int) bool {
+	n := len(t.connections)
+	if n < 2 {
+		return false
+	}
+	t.currentConnectionIndex = ((t.currentConnectionIndex+dir)%n + n) % n
+	return true
+}
+
+func (t *NetworkManagerBlock) openPortal() error {
+	switch t.connectivity {
+	case NM_CONNECTIVITY_PORTAL, NM_CONNECTIVITY_LIMITED:
+	default:
+		return nil
+	}
+	url := t.PortalUrl
+	if url == "" {
+		url = t.connectivityCheckUri
+	}
+	if url == "" {
+		return nil
+	}
+	// Not bound to the blocklet's context, so the browser outlives reloads
+	cmd := exec.Command("xdg-open", url)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+func (t *NetworkManagerBlock) OnEvent(e *I3barClickEvent, ctx context.Context) {
+	if t.dbus == nil {
+		return
+	}
+	var err error
+	switch action := t.Actions.Get(e.Button); action {
+	case nmActionNextConnection, nmActionPrevConnection,
+		nmActionNextWifiProfile, nmActionOpenPortal:
+		t.requestAction(ctx, action)
+	case nmActionToggleWifi:
+		err = t.toggleWireless(ctx)
+	case nmActionToggleNetworking:
+		err = t.toggleNetworking(ctx)
+	case nmActionToggleConnection:
+		err = t.toggleConnection(ctx, t.Connection)
+	}
+	if err != nil {
+		Log.Print(err)
+	}
 }
 
 func init() {
@@ -0,0 +1,267 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+const nmSettingsPath dbus.ObjectPath = "/org/freedesktop/NetworkManager/Settings"
+
+type nmSavedConnection struct {
+	objectPath dbus.ObjectPath
+	id, uuid   string
+}
+
+func (c *nmSavedConnection) matches(name string) bool {
+	return c.id == name || strings.EqualFold(c.uuid, name)
+}
+
+// A connection brought up by an action
+type nmActivation struct {
+	// Active connection object
+	path   dbus.ObjectPath
+	id     string
+	failed bool
+}
+
+func (t *NetworkManagerBlock) activationText() string {
+	a := t.activation
+	if a == nil {
+		return ""
+	}
+	state := "activating"
+	if a.failed {
+		state = "failed"
+	}
+	icon, _ := t.Icons[state].(string)
+	return t.ActivationFormat.Expand(formatting.NamedArgs{
+		"icon":            icon,
+		"state":           state,
+		"connection_name": a.id,
+	})
+}
+
+// Tracks the state of the connection being activated. Reports whether the
+// block should be re-rendered
+func (t *NetworkManagerBlock) onActivationChanged(
+	a *nmActivation,
+	props map[string]dbus.Variant,
+) bool {
+	v, ok := props["State"]
+	if !ok {
+		return false
+	}
+	var state nmActiveConnectionState
+	if err := v.Store(&state); err != nil {
+		return false
+	}
+	return t.onActivationState(a, state)
+}
+
+func (t *NetworkManagerBlock) onActivationState(
+	a *nmActivation,
+	state nmActiveConnectionState,
+) bool {
+	switch state {
+	case NM_ACTIVE_CONNECTION_STATE_ACTIVATED:
+		t.activation = nil
+		return true
+	case NM_ACTIVE_CONNECTION_STATE_DEACTIVATING,
+		NM_ACTIVE_CONNECTION_STATE_DEACTIVATED:
+		if !a.failed {
+			t.activationFailed(a)
+		}
+		return true
+	}
+	return false
+}
+
+// Starts tracking an activation requested by a click. The state may have
+// changed before the activation got here, so it is read once
+func (t *NetworkManagerBlock) startActivation(a *nmActivation) {
+	t.activation = a
+	if a.failed {
+		t.activationFailed(a)
+		return
+	}
+	var state nmActiveConnectionState
+	if err := dbusGetProp(
+		t.dbus.Object(nmDbusDest, a.path),
+		nmDbusDest+".Connection.Active", "State", &state,
+	); err != nil {
+		// The active connection is gone already
+		t.activationFailed(a)
+		return
+	}
+	t.onActivationState(a, state)
+}
+
+// Shows the failed activation for a while
+func (t *NetworkManagerBlock) activationFailed(a *nmActivation) {
+	a.failed = true
+	t.activationExpiry = time.After(nmActivationFailedTimeout)
+}
+
+// Hides the failed activation once it has been shown for long enough
+func (t *NetworkManagerBlock) activationExpired() {
+	if t.activation != nil && t.activation.failed {
+		t.activation = nil
+	}
+	t.activationExpiry = nil
+}
+
+func (t *NetworkManagerBlock) toggleWireless(ctx context.Context) error {
+	o := t.dbus.Object(nmDbusDest, nmDbusBasePath)
+	var enabled bool
+	if err := dbusGetProp(o, nmDbusDest, "WirelessEnabled", &enabled); err != nil {
+		return err
+	}
+	return o.CallWithContext(
+		ctx, dbusPropertiesIface+".Set", 0,
+		nmDbusDest, "WirelessEnabled", dbus.MakeVariant(!enabled),
+	).Err
+}
+
+func (t *NetworkManagerBlock) toggleNetworking(ctx context.Context) error {
+	o := t.dbus.Object(nmDbusDest, nmDbusBasePath)
+	var enabled bool
+	if err := dbusGetProp(o, nmDbusDest, "NetworkingEnabled", &enabled); err != nil {
+		return err
+	}
+	// NetworkingEnabled is read-only
+	return o.CallWithContext(ctx, nmDbusDest+".Enable", 0, !enabled).Err
+}
+
+// Looks up a saved connection by its name or UUID
+func (t *NetworkManagerBlock) findSavedConnection(
+	ctx context.Context,
+	name string,
+) (*nmSavedConnection, error) {
+	var paths []dbus.ObjectPath
+	if err := t.dbus.Object(nmDbusDest, nmSettingsPath).CallWithContext(
+		ctx, nmDbusDest+".Settings.ListConnections", 0,
+	).Store(&paths); err != nil {
+		return nil, err
+	}
+	for _, p := range paths {
+		var settings map[string]map[string]dbus.Variant
+		if err := t.dbus.Object(nmDbusDest, p).CallWithContext(
+			ctx, nmDbusDest+".Settings.Connection.GetSettings", 0,
+		).Store(&settings); err != nil {
+			return nil, err
+		}
+		c := nmSavedConnection{objectPath: p}
+		settings["connection"]["id"].Store(&c.id)
+		settings["connection"]["uuid"].Store(&c.uuid)
+		if c.matches(name) {
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("connection %q not found", name)
+}
+
+// Returns the active connection of the saved one, or "" if it's not active
+func (t *NetworkManagerBlock) findActiveConnection(
+	uuid string,
+) (dbus.ObjectPath, error) {
+	var paths []dbus.ObjectPath
+	if err := dbusGetProp(
+		t.dbus.Object(nmDbusDest, nmDbusBasePath),
+		nmDbusDest, "ActiveConnections", &paths,
+	); err != nil {
+		return "", err
+	}
+	for _, p := range paths {
+		var u string
+		if err := dbusGetProp(
+			t.dbus.Object(nmDbusDest, p),
+			nmDbusDest+".Connection.Active", "Uuid", &u,
+		); err != nil {
+			continue
+		}
+		if u == uuid {
+			return p, nil
+		}
+	}
+	return "", nil
+}
+
+func (t *NetworkManagerBlock) activate(
+	ctx context.Context,
+	c *nmSavedConnection,
+) error {
+	var active dbus.ObjectPath
+	// Let NetworkManager pick the device
+	err := t.dbus.Object(nmDbusDest, nmDbusBasePath).CallWithContext(
+		ctx, nmDbusDest+".ActivateConnection", 0,
+		c.objectPath, dbus.ObjectPath("/"), dbus.ObjectPath("/"),
+	).Store(&active)
+	a := &nmActivation{path: active, id: c.id, failed: err != nil}
+	select {
+	case t.activations <- a:
+	case <-ctx.Done():
+	}
+	return err
+}
+
+// Brings the saved connection up, or down if it's active
+func (t *NetworkManagerBlock) toggleConnection(
+	ctx context.Context,
+	name string,
+) error {
+	if name == "" {
+		return nil
+	}
+	c, err := t.findSavedConnection(ctx, name)
+	if err != nil {
+		return err
+	}
+	active, err := t.findActiveConnection(c.uuid)
+	if err != nil {
+		return err
+	}
+	if active == "" {
+		return t.activate(ctx, c)
+	}
+	return t.dbus.Object(nmDbusDest, nmDbusBasePath).CallWithContext(
+		ctx, nmDbusDest+".DeactivateConnection", 0, active,
+	).Err
+}
+
+func (t *NetworkManagerBlock) activateByName(ctx context.Context, name string) error {
+	c, err := t.findSavedConnection(ctx, name)
+	if err != nil {
+		return err
+	}
+	return t.activate(ctx, c)
+}
+
+// Returns the Wi-Fi profile which follows the active one, or "" if it is
+// the only profile
+func (t *NetworkManagerBlock) nextWifiProfile() string {
+	if len(t.WifiProfiles) == 0 {
+		return ""
+	}
+	current := -1
+	for _, c := range t.connections {
+		if c.typeName() != "wifi" {
+			continue
+		}
+		for i, name := range t.WifiProfiles {
+			if name == c.id || strings.EqualFold(name, c.uuid) {
+				current = i
+				break
+			}
+		}
+	}
+	next := (current + 1) % len(t.WifiProfiles)
+	if next == current {
+		return ""
+	}
+	return t.WifiProfiles[next]
+}
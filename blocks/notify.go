@@ -0,0 +1,54 @@
+package blocks
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+const notificationsDbusDest = "org.freedesktop.Notifications"
+const notificationsDbusPath dbus.ObjectPath = "/org/freedesktop/Notifications"
+
+// Sends desktop notifications, each one replacing the previous
+type desktopNotifier struct {
+	appName string
+	conn    *dbus.Conn
+	lastId  uint32
+}
+
+func newDesktopNotifier(appName string) *desktopNotifier {
+	return &desktopNotifier{appName: appName}
+}
+
+func notificationUrgency(urgency string) byte {
+	switch urgency {
+	case "low":
+		return 0
+	case "critical":
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (n *desktopNotifier) Notify(summary, body, urgency string) error {
+	if n.conn == nil {
+		conn, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return err
+		}
+		n.conn = conn
+	}
+	hints := map[string]dbus.Variant{
+		"urgency": dbus.MakeVariant(notificationUrgency(urgency)),
+	}
+	return n.conn.Object(notificationsDbusDest, notificationsDbusPath).Call(
+		notificationsDbusDest+".Notify", 0,
+		n.appName, n.lastId, "", summary, body, []string{}, hints, int32(-1),
+	).Store(&n.lastId)
+}
+
+func (n *desktopNotifier) Close() error {
+	if n.conn == nil {
+		return nil
+	}
+	return n.conn.Close()
+}
@@ -0,0 +1,156 @@
+package pipewire
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+)
+
+const (
+	TypeNode     = "PipeWire:Interface:Node"
+	TypeDevice   = "PipeWire:Interface:Device"
+	TypeMetadata = "PipeWire:Interface:Metadata"
+)
+
+const (
+	MediaClassSink   = "Audio/Sink"
+	MediaClassSource = "Audio/Source"
+)
+
+// An object as printed by pw-dump
+type Object struct {
+	Id       uint32          `json:"id"`
+	Type     string          `json:"type"`
+	Info     *ObjectInfo     `json:"info"`
+	Metadata []MetadataEntry `json:"metadata"`
+}
+
+type ObjectInfo struct {
+	Props  map[string]interface{}       `json:"props"`
+	Params map[string][]json.RawMessage `json:"params"`
+}
+
+type MetadataEntry struct {
+	Subject uint32          `json:"subject"`
+	Key     string          `json:"key"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// Keeps the state of PipeWire objects, updated with pw-dump output
+type Graph struct {
+	mu      sync.Mutex
+	objects map[uint32]*Object
+	// Metadata values by key, i.e. `default.audio.sink`
+	metadata map[string]json.RawMessage
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		objects:  map[uint32]*Object{},
+		metadata: map[string]json.RawMessage{},
+	}
+}
+
+func isNull(m json.RawMessage) bool {
+	return len(m) == 0 || string(m) == "null"
+}
+
+// Merges objects from a pw-dump message into the graph. In monitor mode
+// pw-dump prints only changed props and params, and removed objects have
+// neither type nor info
+func (g *Graph) apply(objects []Object) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i := range objects {
+		o := &objects[i]
+		for _, e := range o.Metadata {
+			if e.Subject != 0 {
+				continue
+			}
+			if isNull(e.Value) {
+				delete(g.metadata, e.Key)
+			} else {
+				g.metadata[e.Key] = e.Value
+			}
+		}
+		existing, ok := g.objects[o.Id]
+		if !ok {
+			if o.Type != "" {
+				g.objects[o.Id] = o
+			}
+			continue
+		}
+		if o.Type == "" && o.Info == nil && o.Metadata == nil {
+			delete(g.objects, o.Id)
+			continue
+		}
+		if o.Info == nil {
+			continue
+		}
+		if existing.Info == nil {
+			existing.Info = o.Info
+			continue
+		}
+		if o.Info.Props != nil {
+			existing.Info.Props = o.Info.Props
+		}
+		for k, v := range o.Info.Params {
+			if existing.Info.Params == nil {
+				existing.Info.Params = map[string][]json.RawMessage{}
+			}
+			existing.Info.Params[k] = v
+		}
+	}
+}
+
+// Reads messages from pw-dump output until EOF, calling `onUpdate` after
+// each one
+func (g *Graph) Read(r io.Reader, onUpdate func()) error {
+	dec := json.NewDecoder(r)
+	for {
+		var objects []Object
+		if err := dec.Decode(&objects); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		g.apply(objects)
+		onUpdate()
+	}
+}
+
+// Returns name of the default node for `default.audio.sink` or
+// `default.audio.source` key
+func (g *Graph) DefaultNodeName(key string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var value struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(g.metadata[key], &value); err != nil {
+		return ""
+	}
+	return value.Name
+}
+
+// Returns nodes of the media class, sorted by id
+func (g *Graph) Nodes(mediaClass string) []Node {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	nodes := []Node{}
+	for _, o := range g.objects {
+		if o.Type != TypeNode || o.Info == nil {
+			continue
+		}
+		if propString(o.Info.Props, "media.class") != mediaClass {
+			continue
+		}
+		nodes = append(nodes, g.newNode(o))
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Id < nodes[j].Id
+	})
+	return nodes
+}
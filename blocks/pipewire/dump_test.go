@@ -0,0 +1,112 @@
+package pipewire
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDump = `[
+  {
+    "id": 40,
+    "type": "PipeWire:Interface:Device",
+    "info": {
+      "props": { "device.name": "alsa_card.pci" },
+      "params": {
+        "Route": [
+          { "index": 2, "direction": "Output", "device": 1, "description": "Headphones" }
+        ]
+      }
+    }
+  },
+  {
+    "id": 50,
+    "type": "PipeWire:Interface:Node",
+    "info": {
+      "props": {
+        "media.class": "Audio/Sink",
+        "node.name": "alsa_output.pci.analog-stereo",
+        "node.description": "Built-in Audio",
+        "device.id": 40,
+        "card.profile.device": 1
+      },
+      "params": {
+        "Props": [
+          { "mute": false, "channelVolumes": [ 0.125, 0.064 ], "channelMap": [ "FL", "FR" ] }
+        ],
+        "Format": [
+          { "format": "S32LE", "rate": 48000, "channels": 2 }
+        ]
+      }
+    }
+  },
+  {
+    "id": 51,
+    "type": "PipeWire:Interface:Node",
+    "info": {
+      "props": { "media.class": "Audio/Source", "node.name": "mic" },
+      "params": {}
+    }
+  },
+  {
+    "id": 30,
+    "type": "PipeWire:Interface:Metadata",
+    "props": { "metadata.name": "default" },
+    "metadata": [
+      { "subject": 0, "key": "default.audio.sink", "type": "Spa:String:JSON", "value": { "name": "alsa_output.pci.analog-stereo" } }
+    ]
+  }
+]
+[
+  {
+    "id": 50,
+    "info": {
+      "params": {
+        "Props": [
+          { "mute": true, "channelVolumes": [ 1.0, 1.0 ], "channelMap": [ "FL", "FR" ] }
+        ]
+      }
+    }
+  },
+  { "id": 51, "info": null },
+  {
+    "id": 30,
+    "metadata": [
+      { "subject": 0, "key": "default.audio.sink", "value": null }
+    ]
+  }
+]
+`
+
+func TestGraphRead(t *testing.T) {
+	g := NewGraph()
+	updates := 0
+	if err := g.Read(strings.NewReader(testDump), func() { updates++ }); err != nil {
+		t.Fatal(err)
+	}
+	if updates != 2 {
+		t.Errorf("expected 2 updates, got %d", updates)
+	}
+	sinks := g.Nodes(MediaClassSink)
+	if len(sinks) != 1 {
+		t.Fatalf("expected 1 sink, got %d", len(sinks))
+	}
+	n := sinks[0]
+	if n.Name != "alsa_output.pci.analog-stereo" || n.Description != "Built-in Audio" {
+		t.Errorf("unexpected node %+v", n)
+	}
+	if !n.Mute || len(n.ChannelVolumes) != 2 || n.ChannelVolumes[0] != 1 {
+		t.Errorf("props update not applied: %+v", n)
+	}
+	if n.SampleFormat != "S32LE" || n.Rate != 48000 || n.Channels != 2 {
+		t.Errorf("unexpected format: %+v", n)
+	}
+	if n.Port != "Headphones" {
+		t.Errorf("expected port Headphones, got %q", n.Port)
+	}
+	if sources := g.Nodes(MediaClassSource); len(sources) != 0 {
+		t.Errorf("removed node is still present: %+v", sources)
+	}
+	if name := g.DefaultNodeName("default.audio.sink"); name != "" {
+		t.Errorf("removed metadata is still present: %q", name)
+	}
+}
@@ -0,0 +1,71 @@
+package pipewire
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Starts `pw-dump --monitor` and keeps the graph up to date. A value is sent
+// to the returned channel after each change, the channel is closed when
+// pw-dump exits
+func Monitor(ctx context.Context) (*Graph, <-chan struct{}, error) {
+	cmd := exec.CommandContext(ctx, "pw-dump", "--monitor")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	g := NewGraph()
+	updates := make(chan struct{}, 1)
+	go func() {
+		defer close(updates)
+		g.Read(stdout, func() {
+			select {
+			case updates <- struct{}{}:
+			default:
+			}
+		})
+		cmd.Wait()
+	}()
+	return g, updates, nil
+}
+
+func setProps(ctx context.Context, id uint32, props string) error {
+	return exec.CommandContext(
+		ctx, "pw-cli", "set-param", strconv.FormatUint(uint64(id), 10),
+		"Props", props,
+	).Run()
+}
+
+// Sets linear volume of each channel of the node
+func SetChannelVolumes(ctx context.Context, id uint32, volumes []float64) error {
+	values := make([]string, len(volumes))
+	for i, v := range volumes {
+		values[i] = strconv.FormatFloat(v, 'f', 6, 64)
+	}
+	return setProps(ctx, id, fmt.Sprintf(
+		"{ channelVolumes: [ %s ] }", strings.Join(values, ", "),
+	))
+}
+
+func SetMute(ctx context.Context, id uint32, mute bool) error {
+	return setProps(ctx, id, fmt.Sprintf("{ mute: %t }", mute))
+}
+
+// Sets the configured default node for `default.configured.audio.sink` or
+// `default.configured.audio.source` key
+func SetDefaultNode(ctx context.Context, key, name string) error {
+	value, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return err
+	}
+	return exec.CommandContext(
+		ctx, "pw-metadata", "0", key, string(value), "Spa:String:JSON",
+	).Run()
+}
@@ -0,0 +1,120 @@
+package pipewire
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// An audio sink or source
+type Node struct {
+	Id          uint32
+	Name        string
+	Description string
+	// Linear channel volumes
+	ChannelVolumes []float64
+	ChannelMap     []string
+	Mute           bool
+	// I.e. `S16LE`
+	SampleFormat string
+	Rate         int
+	Channels     int
+	// Description of the active device route (port)
+	Port string
+	// Bluetooth codec, i.e. `aac` or `ldac`
+	Codec string
+}
+
+func propString(props map[string]interface{}, key string) string {
+	switch v := props[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func propInt(props map[string]interface{}, key string) (int, bool) {
+	switch v := props[key].(type) {
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+type propsParam struct {
+	Mute           bool      `json:"mute"`
+	ChannelVolumes []float64 `json:"channelVolumes"`
+	ChannelMap     []string  `json:"channelMap"`
+}
+
+type formatParam struct {
+	Format   string `json:"format"`
+	Rate     int    `json:"rate"`
+	Channels int    `json:"channels"`
+}
+
+type routeParam struct {
+	Direction   string `json:"direction"`
+	Device      int    `json:"device"`
+	Description string `json:"description"`
+}
+
+// Must be called with the graph lock held
+func (g *Graph) newNode(o *Object) Node {
+	props := o.Info.Props
+	n := Node{
+		Id:          o.Id,
+		Name:        propString(props, "node.name"),
+		Description: propString(props, "node.description"),
+		Codec:       propString(props, "api.bluez5.codec"),
+	}
+	if n.Description == "" {
+		n.Description = propString(props, "node.nick")
+	}
+	for _, raw := range o.Info.Params["Props"] {
+		var p propsParam
+		if json.Unmarshal(raw, &p) != nil || p.ChannelVolumes == nil {
+			continue
+		}
+		n.Mute = p.Mute
+		n.ChannelVolumes = p.ChannelVolumes
+		n.ChannelMap = p.ChannelMap
+		break
+	}
+	for _, raw := range o.Info.Params["Format"] {
+		var f formatParam
+		if json.Unmarshal(raw, &f) == nil {
+			n.SampleFormat, n.Rate, n.Channels = f.Format, f.Rate, f.Channels
+			break
+		}
+	}
+	n.Port = g.activeRoute(props)
+	return n
+}
+
+// Finds description of the route of node's device which the node belongs to
+func (g *Graph) activeRoute(props map[string]interface{}) string {
+	deviceId, ok := propInt(props, "device.id")
+	if !ok {
+		return ""
+	}
+	profileDevice, ok := propInt(props, "card.profile.device")
+	if !ok {
+		return ""
+	}
+	device, ok := g.objects[uint32(deviceId)]
+	if !ok || device.Info == nil {
+		return ""
+	}
+	for _, raw := range device.Info.Params["Route"] {
+		var r routeParam
+		if json.Unmarshal(raw, &r) == nil && r.Device == profileDevice {
+			return r.Description
+		}
+	}
+	return ""
+}
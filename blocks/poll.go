@@ -0,0 +1,117 @@
+package blocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/kraftwerk28/gost/core"
+)
+
+// Ticks shared by polling blocklets: all of them with the same interval are
+// polled at the same moment from a single ticker, so their values are
+// sampled together. Each blocklet still sends its own update
+type pollScheduler struct {
+	mu     sync.Mutex
+	groups map[time.Duration]*pollGroup
+}
+
+type pollGroup struct {
+	subscribers map[chan struct{}]struct{}
+	stop        chan struct{}
+}
+
+var sharedPollScheduler = pollScheduler{
+	groups: make(map[time.Duration]*pollGroup),
+}
+
+func (s *pollScheduler) subscribe(interval time.Duration) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[interval]
+	if !ok {
+		g = &pollGroup{
+			subscribers: make(map[chan struct{}]struct{}),
+			stop:        make(chan struct{}),
+		}
+		s.groups[interval] = g
+		go s.tick(interval, g)
+	}
+	ch := make(chan struct{}, 1)
+	g.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (s *pollScheduler) unsubscribe(interval time.Duration, ch chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[interval]
+	if !ok {
+		return
+	}
+	delete(g.subscribers, ch)
+	if len(g.subscribers) == 0 {
+		close(g.stop)
+		delete(s.groups, interval)
+	}
+}
+
+func (s *pollScheduler) tick(interval time.Duration, g *pollGroup) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			for ch := range g.subscribers {
+				// A blocklet which is still busy skips the tick
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+			s.mu.Unlock()
+		case <-g.stop:
+			return
+		}
+	}
+}
+
+// Calls `poll` right away and then every `interval` until the context is
+// done. The blocklet is re-rendered after each call
+func runPolling(
+	ctx context.Context,
+	ch UpdateChan,
+	interval time.Duration,
+	poll func() error,
+) {
+	if err := poll(); err != nil {
+		Log.Print(err)
+	}
+	ch.SendUpdate()
+	ticks := sharedPollScheduler.subscribe(interval)
+	defer sharedPollScheduler.unsubscribe(interval, ticks)
+	for {
+		select {
+		case <-ticks:
+			if err := poll(); err != nil {
+				Log.Print(err)
+			}
+			ch.SendUpdate()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Block color for a usage percentage, from green to red. Usage below
+// `threshold` isn't colored
+func usageColor(cfg *AppConfig, percentage float64, threshold int) string {
+	if cfg == nil || cfg.Theme == nil || percentage < float64(threshold) {
+		return ""
+	}
+	if percentage > 100 {
+		percentage = 100
+	}
+	return cfg.Theme.HSVColor(PercentageToHue(100 - int(percentage))).String()
+}
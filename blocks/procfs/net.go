@@ -0,0 +1,83 @@
+package procfs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const Root = "/proc"
+
+// Counters of a network interface from /proc/net/dev
+type NetDev struct {
+	Name      string
+	RxBytes   uint64
+	RxPackets uint64
+	TxBytes   uint64
+	TxPackets uint64
+}
+
+// Reads counters of all network interfaces. `root` is usually `Root`
+func ReadNetDev(root string) ([]NetDev, error) {
+	f, err := os.Open(filepath.Join(root, "net", "dev"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	devices := []NetDev{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		name, counters, ok := strings.Cut(sc.Text(), ":")
+		// Skip the two header lines
+		if !ok || strings.Contains(name, "|") {
+			continue
+		}
+		fields := strings.Fields(counters)
+		if len(fields) < 10 {
+			continue
+		}
+		d := NetDev{Name: strings.TrimSpace(name)}
+		d.RxBytes, _ = strconv.ParseUint(fields[0], 10, 64)
+		d.RxPackets, _ = strconv.ParseUint(fields[1], 10, 64)
+		d.TxBytes, _ = strconv.ParseUint(fields[8], 10, 64)
+		d.TxPackets, _ = strconv.ParseUint(fields[9], 10, 64)
+		devices = append(devices, d)
+	}
+	return devices, sc.Err()
+}
+
+// Returns the interface of the IPv4 default route with the lowest metric,
+// or "" if there's no default route
+func DefaultRouteInterface(root string) (string, error) {
+	f, err := os.Open(filepath.Join(root, "net", "route"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	const routeFlagUp = 0x1
+	iface := ""
+	var bestMetric uint64
+	sc := bufio.NewScanner(f)
+	sc.Scan() // Skip the header
+	for sc.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&routeFlagUp == 0 {
+			continue
+		}
+		metric, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			continue
+		}
+		if iface == "" || metric < bestMetric {
+			iface, bestMetric = fields[0], metric
+		}
+	}
+	return iface, sc.Err()
+}
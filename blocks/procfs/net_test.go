@@ -0,0 +1,55 @@
+package procfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFakeFile(t *testing.T, root, name, content string) {
+	p := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadNetDev(t *testing.T) {
+	root := t.TempDir()
+	writeFakeFile(t, root, "net/dev", `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:    1200      12    0    0    0     0          0         0     1200      12    0    0    0     0       0          0
+wlan0: 987654321  654321    0    3    0     0          0         0 12345678   43210    0    0    0     0       0          0
+`)
+	devices, err := ReadNetDev(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("Expected 2 interfaces, got %d", len(devices))
+	}
+	wlan := devices[1]
+	if wlan.Name != "wlan0" || wlan.RxBytes != 987654321 || wlan.RxPackets != 654321 ||
+		wlan.TxBytes != 12345678 || wlan.TxPackets != 43210 {
+		t.Errorf("Unexpected wlan0: %+v", wlan)
+	}
+}
+
+func TestDefaultRouteInterface(t *testing.T) {
+	root := t.TempDir()
+	writeFakeFile(t, root, "net/route", `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+wlan0	00000000	0101A8C0	0003	0	0	600	00000000	0	0	0
+wlan0	0001A8C0	00000000	0001	0	0	600	00FFFFFF	0	0	0
+eth0	00000000	0100000A	0003	0	0	100	00000000	0	0	0
+tun0	00000000	00000000	0000	0	0	50	00000000	0	0	0
+`)
+	iface, err := DefaultRouteInterface(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iface != "eth0" {
+		t.Errorf(`Expected "eth0", got "%s"`, iface)
+	}
+}
@@ -0,0 +1,160 @@
+package procfs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Time spent by a CPU in each mode, in USER_HZ, from /proc/stat
+type CPUStat struct {
+	// `cpu` for the sum of all cores, `cpu0`, `cpu1` etc. otherwise
+	Name    string
+	User    uint64
+	Nice    uint64
+	System  uint64
+	Idle    uint64
+	IOWait  uint64
+	IRQ     uint64
+	SoftIRQ uint64
+	Steal   uint64
+}
+
+func (s *CPUStat) Total() uint64 {
+	return s.User + s.Nice + s.System + s.Idle + s.IOWait + s.IRQ +
+		s.SoftIRQ + s.Steal
+}
+
+func (s *CPUStat) Busy() uint64 {
+	return s.Total() - s.Idle - s.IOWait
+}
+
+// Percentage of time the CPU was busy between two samples
+func CPUUsage(prev, cur CPUStat) float64 {
+	total := float64(cur.Total()) - float64(prev.Total())
+	if total <= 0 {
+		return 0
+	}
+	busy := float64(cur.Busy()) - float64(prev.Busy())
+	if busy < 0 {
+		return 0
+	}
+	return busy / total * 100
+}
+
+// Reads the total CPU time, followed by each core
+func ReadStat(root string) ([]CPUStat, error) {
+	f, err := os.Open(filepath.Join(root, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stats := []CPUStat{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 9 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		var n [8]uint64
+		for i := range n {
+			n[i], _ = strconv.ParseUint(fields[i+1], 10, 64)
+		}
+		stats = append(stats, CPUStat{
+			fields[0], n[0], n[1], n[2], n[3], n[4], n[5], n[6], n[7],
+		})
+	}
+	return stats, sc.Err()
+}
+
+// Memory usage from /proc/meminfo, in bytes
+type Meminfo struct {
+	Total     uint64
+	Free      uint64
+	Available uint64
+	Buffers   uint64
+	Cached    uint64
+	SwapTotal uint64
+	SwapFree  uint64
+}
+
+func (m *Meminfo) Used() uint64 {
+	return m.Total - m.Available
+}
+
+func (m *Meminfo) SwapUsed() uint64 {
+	return m.SwapTotal - m.SwapFree
+}
+
+func ReadMeminfo(root string) (*Meminfo, error) {
+	f, err := os.Open(filepath.Join(root, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m := Meminfo{}
+	fields := map[string]*uint64{
+		"MemTotal":     &m.Total,
+		"MemFree":      &m.Free,
+		"MemAvailable": &m.Available,
+		"Buffers":      &m.Buffers,
+		"Cached":       &m.Cached,
+		"SwapTotal":    &m.SwapTotal,
+		"SwapFree":     &m.SwapFree,
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// MemTotal:       16318240 kB
+		key, value, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			continue
+		}
+		p, ok := fields[key]
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		mult := uint64(1)
+		if strings.HasSuffix(value, " kB") {
+			value, mult = strings.TrimSuffix(value, " kB"), 1024
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("meminfo %s: %w", key, err)
+		}
+		*p = n * mult
+	}
+	return &m, sc.Err()
+}
+
+// System load from /proc/loadavg
+type Loadavg struct {
+	Load1, Load5, Load15 float64
+	// Number of currently runnable and existing kernel scheduling entities
+	Running, Total int
+}
+
+func ReadLoadavg(root string) (*Loadavg, error) {
+	b, err := os.ReadFile(filepath.Join(root, "loadavg"))
+	if err != nil {
+		return nil, err
+	}
+	// 0.52 0.58 0.59 2/1234 56789
+	fields := strings.Fields(string(b))
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("unexpected loadavg: %q", b)
+	}
+	l := Loadavg{}
+	for i, p := range []*float64{&l.Load1, &l.Load5, &l.Load15} {
+		if *p, err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return nil, err
+		}
+	}
+	running, total, _ := strings.Cut(fields[3], "/")
+	l.Running, _ = strconv.Atoi(running)
+	l.Total, _ = strconv.Atoi(total)
+	return &l, nil
+}
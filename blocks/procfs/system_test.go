@@ -0,0 +1,63 @@
+package procfs
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReadStat(t *testing.T) {
+	root := t.TempDir()
+	writeFakeFile(t, root, "stat", `cpu  400 0 100 1400 100 0 0 0 0 0
+cpu0 300 0 50 600 50 0 0 0 0 0
+cpu1 100 0 50 800 50 0 0 0 0 0
+intr 123456 0 0
+ctxt 654321
+`)
+	stats, err := ReadStat(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 || stats[0].Name != "cpu" || stats[2].Name != "cpu1" {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	prev := stats[1]
+	cur := prev
+	cur.User += 60
+	cur.Idle += 40
+	if u := CPUUsage(prev, cur); math.Abs(u-60) > 1e-9 {
+		t.Errorf("Expected 60%% usage, got %f", u)
+	}
+}
+
+func TestReadMeminfo(t *testing.T) {
+	root := t.TempDir()
+	writeFakeFile(t, root, "meminfo", `MemTotal:       16000000 kB
+MemFree:         2000000 kB
+MemAvailable:    6000000 kB
+Buffers:          500000 kB
+Cached:          3000000 kB
+SwapTotal:       8000000 kB
+SwapFree:        7000000 kB
+HugePages_Total:       0
+`)
+	m, err := ReadMeminfo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Used() != 10000000*1024 || m.SwapUsed() != 1000000*1024 ||
+		m.Cached != 3000000*1024 {
+		t.Errorf("Unexpected meminfo: %+v", m)
+	}
+}
+
+func TestReadLoadavg(t *testing.T) {
+	root := t.TempDir()
+	writeFakeFile(t, root, "loadavg", "0.52 0.58 1.25 2/1234 56789\n")
+	l, err := ReadLoadavg(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Load1 != 0.52 || l.Load15 != 1.25 || l.Running != 2 || l.Total != 1234 {
+		t.Errorf("Unexpected loadavg: %+v", l)
+	}
+}
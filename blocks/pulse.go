@@ -4,11 +4,11 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"path"
 	"time"
 
 	. "github.com/kraftwerk28/gost/core"
 	"github.com/kraftwerk28/gost/core/formatting"
-	"github.com/kraftwerk28/pulseaudio"
 )
 
 const (
@@ -16,138 +16,107 @@ const (
 	nodeKindSource = "source"
 )
 
+const (
+	pulseActionVolumeUp   = "volume_up"
+	pulseActionVolumeDown = "volume_down"
+	pulseActionToggleMute = "toggle_mute"
+	pulseActionNextDevice = "next_device"
+	pulseActionPrevDevice = "prev_device"
+	pulseActionMixer      = "mixer"
+)
+
+// Volume above this value is distorted heavily, so `max_volume` is capped
+const pulseMaxVolumeCap = 150
+
 type PulseIconsConfig struct {
-	Devices     map[string]string `yaml:"devices"`
-	SinkMuted   string            `yaml:"sink_muted"`
-	SourceMuted string            `yaml:"source_muted"`
+	// Keyed by active port description
+	Devices map[string]string `yaml:"devices"`
+	// Used if there's no icon for the port, from the quietest to the loudest
+	Volume      []string `yaml:"volume"`
+	SinkMuted   string   `yaml:"sink_muted"`
+	SourceMuted string   `yaml:"source_muted"`
 }
 
+// Available actions: `volume_up`, `volume_down`, `toggle_mute`,
+// `next_device`, `prev_device`, `mixer`
 type PulseConfig struct {
 	BaseBlockletConfig `yaml:",inline"`
-	Node               string           `yaml:"node"`
-	Format             *ConfigFormat    `yaml:"format"`
-	Icons              PulseIconsConfig `yaml:"icons"`
+	// `pulse` or `pipewire`. The latter uses `pw-dump`, `pw-cli` and
+	// `pw-metadata` tools instead of pipewire-pulse
+	Backend string           `yaml:"backend"`
+	Node    string           `yaml:"node"`
+	Format  *ConfigFormat    `yaml:"format"`
+	Icons   PulseIconsConfig `yaml:"icons"`
+	// Volume step in percents
+	Step int `yaml:"step"`
+	// Maximum volume in percents, up to 150
+	MaxVolume int `yaml:"max_volume"`
+	// Command which is run by `mixer` action, i.e. `pavucontrol`
+	Mixer string `yaml:"mixer"`
+	// Glob patterns matched against device name or description.
+	// `next_device` and `prev_device` only cycle through devices which match
+	// any of `include` (if set) and none of `exclude`
+	Include []string            `yaml:"include"`
+	Exclude []string            `yaml:"exclude"`
+	Actions ConfigButtonActions `yaml:"actions"`
 }
 
 type PulseBlock struct {
 	PulseConfig
-	client   *pulseaudio.Client
-	volume   uint32
-	muted    bool
-	portDesc string
-	// icon   string
+	server audioServer
+	device *pulseDevice
 }
 
 func NewPulseBlock() I3barBlocklet {
-	return &PulseBlock{}
-}
-
-func volumeToPercentage(v uint32) uint32 {
-	ratio := float64(v) / 0xffff
-	return uint32(math.Round(ratio * 100))
+	b := PulseBlock{}
+	b.Backend = audioBackendPulse
+	b.Step = 1
+	b.MaxVolume = 100
+	b.Actions = ConfigButtonActions{
+		"scroll_up":   pulseActionVolumeUp,
+		"scroll_down": pulseActionVolumeDown,
+	}
+	return &b
 }
 
 // Updates from pulse server are bursting, so some throttling is required
 const throttleDuration = time.Millisecond * 50
 
 func (c *PulseBlock) fetchInfo() bool {
-	switch c.Node {
-	case nodeKindSource:
-		source, err := c.getCurrentSource()
-		if source == nil {
-			return false
-		}
-		if err != nil {
-			Log.Print(err)
-		}
-		c.volume = volumeToPercentage(source.Cvolume[0])
-		if source.Muted {
-			c.muted = true
-			break
-		}
-		c.muted = false
-		for _, port := range source.Ports {
-			if port.Name == source.ActivePortName {
-				c.portDesc = port.Description
-				break
-			}
-		}
-	case nodeKindSink:
-		sink, err := c.getCurrentSink()
-		if sink == nil {
-			return false
-		}
-		if err != nil {
-			Log.Print(err)
-		}
-		c.volume = volumeToPercentage(sink.Cvolume[0])
-		if sink.Muted {
-			c.muted = true
-			break
-		}
-		c.muted = false
-		for _, port := range sink.Ports {
-			if port.Name == sink.ActivePortName {
-				c.portDesc = port.Description
-				break
-			}
-		}
-	}
-	return true
-}
-
-func (c *PulseBlock) getCurrentSink() (*pulseaudio.Sink, error) {
-	srv, err := c.client.ServerInfo()
-	if err != nil {
-		return nil, err
-	}
-	sinks, err := c.client.Sinks()
-	if err != nil {
-		return nil, err
-	}
-	for _, sink := range sinks {
-		if sink.Name == srv.DefaultSink {
-			return &sink, nil
-		}
-	}
-	return nil, nil
-}
-
-func (c *PulseBlock) getCurrentSource() (*pulseaudio.Source, error) {
-	srv, err := c.client.ServerInfo()
-	if err != nil {
-		return nil, err
-	}
-	sources, err := c.client.Sources()
+	device, err := c.server.defaultDevice(c.Node)
 	if err != nil {
-		return nil, err
+		Log.Print(err)
 	}
-	for _, source := range sources {
-		if source.Name == srv.DefaultSource {
-			return &source, nil
-		}
+	if device == nil {
+		return false
 	}
-	return nil, nil
+	c.device = device
+	return true
 }
 
 func (c *PulseBlock) Run(ch UpdateChan, ctx context.Context) {
-	client, err := pulseaudio.NewClient()
-	if err != nil {
-		Log.Print(err)
-		return
+	var upd <-chan struct{}
+	var err error
+	switch c.Backend {
+	case audioBackendPipewire:
+		c.server, upd, err = newPipewireServer(ctx)
+	default:
+		c.server, upd, err = newPulseServer()
 	}
-	defer client.Close()
-	c.client = client
-	upd, err := client.Updates()
 	if err != nil {
 		Log.Print(err)
 		return
 	}
+	defer c.server.Close()
 	c.fetchInfo()
 	throttleTimer := time.NewTimer(throttleDuration)
 	for {
 		select {
-		case <-upd:
+		case _, ok := <-upd:
+			if !ok {
+				Log.Printf("%s backend has exited", c.Backend)
+				return
+			}
 			throttleTimer.Reset(throttleDuration)
 		case <-throttleTimer.C:
 			if c.fetchInfo() {
@@ -163,9 +132,27 @@ func (c *PulseBlock) GetConfig() interface{} {
 	return &c.PulseConfig
 }
 
+// Picks an icon from `icons.volume`, which are evenly spread over 0..100%
+func (t *PulseBlock) volumeIcon(volume int) string {
+	n := len(t.Icons.Volume)
+	if n == 0 {
+		return ""
+	}
+	i := volume * n / 100
+	if i >= n {
+		i = n - 1
+	}
+	return t.Icons.Volume[i]
+}
+
 func (t *PulseBlock) Render(cfg *AppConfig) []I3barBlock {
+	d := t.device
+	if d == nil {
+		return nil
+	}
+	volume := volumeToPercentage(d.maxVolume())
 	var icon string
-	if t.muted {
+	if d.muted {
 		switch t.Node {
 		case nodeKindSink:
 			icon = t.Icons.SinkMuted
@@ -177,48 +164,143 @@ func (t *PulseBlock) Render(cfg *AppConfig) []I3barBlock {
 			cfg.Theme.HSVColor(0),
 			icon,
 		)
+	} else if i, ok := t.Icons.Devices[d.port]; ok {
+		icon = i
 	} else {
-		icon = t.Icons.Devices[t.portDesc]
+		icon = t.volumeIcon(volume)
 	}
 	return []I3barBlock{{
 		FullText: t.Format.Expand(formatting.NamedArgs{
-			"icon":   icon,
-			"volume": t.volume,
+			"icon":        icon,
+			"volume":      volume,
+			"volume_max":  volume,
+			"volume_avg":  volumeToPercentage(d.avgVolume()),
+			"volume_db":   formatVolumeDb(d.maxVolume()),
+			"balance":     int(math.Round(d.balance() * 100)),
+			"device_name": d.name,
+			"device_desc": d.description,
+			"port":        d.port,
+			"sample_spec": d.sampleSpec,
+			"codec":       d.codec,
 		}),
 		Name:   nodeKindSink,
 		Markup: MarkupPango,
 	}}
 }
 
-func (t *PulseBlock) changeVolume(delta int) error {
-	d := float32(delta) * 0.01
-	switch t.Node {
-	case nodeKindSink:
-		s, err := t.getCurrentSink()
-		if err != nil {
-			return err
+// Changes volume by `delta` percents, keeping it in [0, max_volume] range.
+// Channel volumes are scaled, so the balance between them is preserved
+func (t *PulseBlock) changeVolume(ctx context.Context, delta int) error {
+	d, err := t.server.defaultDevice(t.Node)
+	if err != nil || d == nil {
+		return err
+	}
+	maxVolume := t.MaxVolume
+	if maxVolume > pulseMaxVolumeCap {
+		maxVolume = pulseMaxVolumeCap
+	}
+	current := volumeToPercentage(d.maxVolume())
+	target := current + delta
+	// Volume raised above the limit elsewhere is only ever lowered
+	if delta > 0 {
+		if current >= maxVolume {
+			return nil
+		}
+		if target > maxVolume {
+			target = maxVolume
+		}
+	}
+	if target < 0 {
+		target = 0
+	}
+	volume := d.scaledVolume(percentageToVolume(target))
+	return t.server.setVolume(ctx, t.Node, d, volume)
+}
+
+func (t *PulseBlock) toggleMute(ctx context.Context) error {
+	d, err := t.server.defaultDevice(t.Node)
+	if err != nil || d == nil {
+		return err
+	}
+	return t.server.toggleMute(ctx, t.Node, d)
+}
+
+func matchesAnyPattern(patterns []string, values ...string) bool {
+	for _, p := range patterns {
+		for _, v := range values {
+			if ok, _ := path.Match(p, v); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *PulseBlock) deviceAllowed(name, desc string) bool {
+	if len(t.Include) > 0 && !matchesAnyPattern(t.Include, name, desc) {
+		return false
+	}
+	return !matchesAnyPattern(t.Exclude, name, desc)
+}
+
+func (t *PulseBlock) cycleDevice(ctx context.Context, step int) error {
+	current, err := t.server.defaultDevice(t.Node)
+	if err != nil {
+		return err
+	}
+	all, err := t.server.devices(t.Node)
+	if err != nil {
+		return err
+	}
+	devices := []*pulseDevice{}
+	for _, d := range all {
+		if t.deviceAllowed(d.name, d.description) {
+			devices = append(devices, d)
 		}
-		vol := float32(s.Cvolume[0]) / 0xffff
-		vol += d
-		return t.client.SetSinkVolume(s.Name, vol)
-	case nodeKindSource:
-		s, err := t.getCurrentSource()
-		if err != nil {
-			return err
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+	next := 0
+	for i, d := range devices {
+		if current != nil && d.name == current.name {
+			next = (i + step + len(devices)) % len(devices)
+			break
 		}
-		vol := float32(s.Cvolume[0]) / 0xffff
-		vol += d
-		return t.client.SetSourceVolume(s.Name, vol)
 	}
-	return nil
+	if current != nil && devices[next].name == current.name {
+		return nil
+	}
+	return t.server.setDefault(ctx, t.Node, devices[next])
 }
 
 func (t *PulseBlock) OnEvent(e *I3barClickEvent, ctx context.Context) {
-	switch e.Button {
-	case ButtonScrollDown:
-		t.changeVolume(-1)
-	case ButtonScrollUp:
-		t.changeVolume(+1)
+	if t.server == nil {
+		return
+	}
+	var err error
+	switch t.Actions.Get(e.Button) {
+	case pulseActionVolumeUp:
+		err = t.changeVolume(ctx, t.Step)
+	case pulseActionVolumeDown:
+		err = t.changeVolume(ctx, -t.Step)
+	case pulseActionToggleMute:
+		err = t.toggleMute(ctx)
+	case pulseActionNextDevice:
+		err = t.cycleDevice(ctx, 1)
+	case pulseActionPrevDevice:
+		err = t.cycleDevice(ctx, -1)
+	case pulseActionMixer:
+		if t.Mixer == "" {
+			return
+		}
+		cmd := e.ShellCommand(t.Mixer, ctx)
+		if err = cmd.Start(); err == nil {
+			go cmd.Wait()
+		}
+	}
+	if err != nil {
+		Log.Print(err)
 	}
 }
 
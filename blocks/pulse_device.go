@@ -0,0 +1,211 @@
+package blocks
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/kraftwerk28/gost/blocks/pipewire"
+	"github.com/kraftwerk28/pulseaudio"
+)
+
+// Volume value which corresponds to 100%
+const pulseVolumeNorm = 0xffff
+
+// Names of pa_sample_format values
+var pulseSampleFormats = []string{
+	"u8", "aLaw", "uLaw", "s16le", "s16be", "float32le", "float32be",
+	"s32le", "s32be", "s24le", "s24be", "s24-32le", "s24-32be",
+}
+
+// pa_channel_position values of left and right channels
+var (
+	pulseLeftChannels  = map[byte]bool{1: true, 5: true, 8: true, 10: true, 45: true, 48: true}
+	pulseRightChannels = map[byte]bool{2: true, 6: true, 9: true, 11: true, 46: true, 49: true}
+)
+
+// pa_channel_position values of PipeWire channel names
+var pipewireChannelPositions = map[string]byte{
+	"MONO": 0, "FL": 1, "FR": 2, "FC": 3, "RL": 5, "RR": 6, "LFE": 7,
+	"FLC": 8, "FRC": 9, "SL": 10, "SR": 11, "TFL": 45, "TFR": 46,
+	"TRL": 48, "TRR": 49,
+}
+
+// Common part of a sink and a source
+type pulseDevice struct {
+	// PipeWire node id
+	id          uint32
+	name        string
+	description string
+	// Description of the active port
+	port       string
+	sampleSpec string
+	channelMap []byte
+	volume     []uint32
+	muted      bool
+	// Bluetooth codec, only known with PipeWire backend
+	codec string
+}
+
+func formatSampleSpec(format byte, channels byte, rate uint32) string {
+	f := "invalid"
+	if int(format) < len(pulseSampleFormats) {
+		f = pulseSampleFormats[format]
+	}
+	return fmt.Sprintf("%s %dch %dHz", f, channels, rate)
+}
+
+func newPulseDeviceFromSink(s *pulseaudio.Sink) *pulseDevice {
+	d := pulseDevice{
+		name:        s.Name,
+		description: s.Description,
+		sampleSpec: formatSampleSpec(
+			s.SampleSpec.Format,
+			s.SampleSpec.Channels,
+			s.SampleSpec.Rate,
+		),
+		channelMap: s.ChannelMap,
+		volume:     s.Cvolume,
+		muted:      s.Muted,
+	}
+	for _, port := range s.Ports {
+		if port.Name == s.ActivePortName {
+			d.port = port.Description
+			break
+		}
+	}
+	return &d
+}
+
+func newPulseDeviceFromSource(s *pulseaudio.Source) *pulseDevice {
+	d := pulseDevice{
+		name:        s.Name,
+		description: s.Description,
+		sampleSpec: formatSampleSpec(
+			s.SampleSpec.Format,
+			s.SampleSpec.Channels,
+			s.SampleSpec.Rate,
+		),
+		channelMap: s.ChannelMap,
+		volume:     s.Cvolume,
+		muted:      s.Muted,
+	}
+	for _, port := range s.Ports {
+		if port.Name == s.ActivePortName {
+			d.port = port.Description
+			break
+		}
+	}
+	return &d
+}
+
+func newPulseDeviceFromNode(n *pipewire.Node) *pulseDevice {
+	format := strings.ToLower(n.SampleFormat)
+	if strings.HasPrefix(format, "f32") {
+		format = "float32" + format[3:]
+	}
+	d := pulseDevice{
+		id:          n.Id,
+		name:        n.Name,
+		description: n.Description,
+		port:        n.Port,
+		sampleSpec:  fmt.Sprintf("%s %dch %dHz", format, n.Channels, n.Rate),
+		channelMap:  make([]byte, len(n.ChannelMap)),
+		volume:      make([]uint32, len(n.ChannelVolumes)),
+		muted:       n.Mute,
+		codec:       n.Codec,
+	}
+	for i, ch := range n.ChannelMap {
+		d.channelMap[i] = pipewireChannelPositions[ch]
+	}
+	// PipeWire channel volumes are linear, while pulse ones are cubic
+	for i, v := range n.ChannelVolumes {
+		d.volume[i] = uint32(math.Round(math.Cbrt(v) * pulseVolumeNorm))
+	}
+	return &d
+}
+
+func (d *pulseDevice) maxVolume() uint32 {
+	var max uint32
+	for _, v := range d.volume {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+func (d *pulseDevice) avgVolume() uint32 {
+	if len(d.volume) == 0 {
+		return 0
+	}
+	var sum uint64
+	for _, v := range d.volume {
+		sum += uint64(v)
+	}
+	return uint32(sum / uint64(len(d.volume)))
+}
+
+// Returns balance between left and right channels in [-1, 1] range, the same
+// way pa_cvolume_get_balance does
+func (d *pulseDevice) balance() float64 {
+	var left, right float64
+	var nLeft, nRight int
+	for i, pos := range d.channelMap {
+		if i >= len(d.volume) {
+			break
+		}
+		if pulseLeftChannels[pos] {
+			left += float64(d.volume[i])
+			nLeft++
+		} else if pulseRightChannels[pos] {
+			right += float64(d.volume[i])
+			nRight++
+		}
+	}
+	if nLeft == 0 || nRight == 0 {
+		return 0
+	}
+	left /= float64(nLeft)
+	right /= float64(nRight)
+	if left == right {
+		return 0
+	}
+	if left > right {
+		return right/left - 1
+	}
+	return 1 - left/right
+}
+
+// Returns channel volumes scaled so that the loudest one is equal to `max`
+func (d *pulseDevice) scaledVolume(max uint32) []uint32 {
+	current := d.maxVolume()
+	result := make([]uint32, len(d.volume))
+	for i, v := range d.volume {
+		if current == 0 {
+			result[i] = max
+		} else {
+			result[i] = uint32(math.Round(float64(v) * float64(max) / float64(current)))
+		}
+	}
+	return result
+}
+
+func volumeToPercentage(v uint32) int {
+	ratio := float64(v) / pulseVolumeNorm
+	return int(math.Round(ratio * 100))
+}
+
+func percentageToVolume(p int) uint32 {
+	return uint32(math.Round(float64(p) / 100 * pulseVolumeNorm))
+}
+
+// Pulse volume is cubic, so decibels are 20·log10(ratio³)
+func formatVolumeDb(v uint32) string {
+	if v == 0 {
+		return "-inf"
+	}
+	db := 60 * math.Log10(float64(v)/pulseVolumeNorm)
+	return strconv.FormatFloat(db, 'f', 1, 64)
+}
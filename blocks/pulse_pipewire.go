@@ -0,0 +1,85 @@
+package blocks
+
+import (
+	"context"
+	"math"
+
+	"github.com/kraftwerk28/gost/blocks/pipewire"
+)
+
+// Talks to PipeWire through its command line tools rather than the native
+// protocol. State is read from `pw-dump --monitor`, changes are made with
+// `pw-cli` and `pw-metadata`
+type pipewireServer struct {
+	graph *pipewire.Graph
+}
+
+func newPipewireServer(
+	ctx context.Context,
+) (*pipewireServer, <-chan struct{}, error) {
+	graph, upd, err := pipewire.Monitor(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pipewireServer{graph}, upd, nil
+}
+
+// pw-dump is stopped along with the context
+func (s *pipewireServer) Close() {}
+
+func pipewireMediaClass(kind string) string {
+	if kind == nodeKindSource {
+		return pipewire.MediaClassSource
+	}
+	return pipewire.MediaClassSink
+}
+
+func (s *pipewireServer) devices(kind string) ([]*pulseDevice, error) {
+	nodes := s.graph.Nodes(pipewireMediaClass(kind))
+	result := make([]*pulseDevice, len(nodes))
+	for i := range nodes {
+		result[i] = newPulseDeviceFromNode(&nodes[i])
+	}
+	return result, nil
+}
+
+func (s *pipewireServer) defaultDevice(kind string) (*pulseDevice, error) {
+	name := s.graph.DefaultNodeName("default.audio." + kind)
+	devices, _ := s.devices(kind)
+	for _, d := range devices {
+		if d.name == name {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *pipewireServer) setVolume(
+	ctx context.Context,
+	kind string,
+	d *pulseDevice,
+	volume []uint32,
+) error {
+	// PipeWire channel volumes are linear, while pulse ones are cubic
+	linear := make([]float64, len(volume))
+	for i, v := range volume {
+		linear[i] = math.Pow(float64(v)/pulseVolumeNorm, 3)
+	}
+	return pipewire.SetChannelVolumes(ctx, d.id, linear)
+}
+
+func (s *pipewireServer) toggleMute(
+	ctx context.Context,
+	kind string,
+	d *pulseDevice,
+) error {
+	return pipewire.SetMute(ctx, d.id, !d.muted)
+}
+
+func (s *pipewireServer) setDefault(
+	ctx context.Context,
+	kind string,
+	d *pulseDevice,
+) error {
+	return pipewire.SetDefaultNode(ctx, "default.configured.audio."+kind, d.name)
+}
@@ -0,0 +1,148 @@
+package blocks
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/pulseaudio"
+)
+
+const (
+	audioBackendPulse    = "pulse"
+	audioBackendPipewire = "pipewire"
+)
+
+// Source's MonitorSourceIndex is set to this value if it isn't a monitor
+const pulseInvalidIndex = 0xffffffff
+
+// Audio server the pulseaudio blocklet talks to. `kind` is either
+// `sink` or `source`
+type audioServer interface {
+	// Returns the default device, nil if there's none
+	defaultDevice(kind string) (*pulseDevice, error)
+	// Returns all devices, except monitors of sinks
+	devices(kind string) ([]*pulseDevice, error)
+	setVolume(ctx context.Context, kind string, d *pulseDevice, volume []uint32) error
+	toggleMute(ctx context.Context, kind string, d *pulseDevice) error
+	setDefault(ctx context.Context, kind string, d *pulseDevice) error
+	Close()
+}
+
+type pulseServer struct {
+	client *pulseaudio.Client
+}
+
+func newPulseServer() (*pulseServer, <-chan struct{}, error) {
+	client, err := pulseaudio.NewClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	upd, err := client.Updates()
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return &pulseServer{client}, upd, nil
+}
+
+func (s *pulseServer) Close() {
+	s.client.Close()
+}
+
+func (s *pulseServer) devices(kind string) ([]*pulseDevice, error) {
+	result := []*pulseDevice{}
+	switch kind {
+	case nodeKindSink:
+		sinks, err := s.client.Sinks()
+		if err != nil {
+			return nil, err
+		}
+		for i := range sinks {
+			result = append(result, newPulseDeviceFromSink(&sinks[i]))
+		}
+	case nodeKindSource:
+		sources, err := s.client.Sources()
+		if err != nil {
+			return nil, err
+		}
+		for i := range sources {
+			if sources[i].MonitorSourceIndex != pulseInvalidIndex {
+				continue
+			}
+			result = append(result, newPulseDeviceFromSource(&sources[i]))
+		}
+	}
+	return result, nil
+}
+
+func (s *pulseServer) defaultDevice(kind string) (*pulseDevice, error) {
+	srv, err := s.client.ServerInfo()
+	if err != nil {
+		return nil, err
+	}
+	name := srv.DefaultSink
+	if kind == nodeKindSource {
+		name = srv.DefaultSource
+	}
+	devices, err := s.devices(kind)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		if d.name == name {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
+// The client library doesn't expose muting sources, switching default
+// devices and setting per-channel volume, so pactl is used for these
+func pactl(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "pactl", args...)
+	cmd.Stdout, cmd.Stderr = Log.Writer(), Log.Writer()
+	return cmd.Run()
+}
+
+func (s *pulseServer) setVolume(
+	ctx context.Context,
+	kind string,
+	d *pulseDevice,
+	volume []uint32,
+) error {
+	uniform := true
+	for _, v := range volume {
+		uniform = uniform && v == volume[0]
+	}
+	// The client can only set the same volume for all channels
+	if uniform && len(volume) > 0 {
+		v := float32(volume[0]) / pulseVolumeNorm
+		if kind == nodeKindSource {
+			return s.client.SetSourceVolume(d.name, v)
+		}
+		return s.client.SetSinkVolume(d.name, v)
+	}
+	args := []string{"set-" + kind + "-volume", d.name}
+	for _, v := range volume {
+		args = append(args, strconv.FormatUint(uint64(v), 10))
+	}
+	return pactl(ctx, args...)
+}
+
+func (s *pulseServer) toggleMute(
+	ctx context.Context,
+	kind string,
+	d *pulseDevice,
+) error {
+	return pactl(ctx, "set-"+kind+"-mute", d.name, "toggle")
+}
+
+func (s *pulseServer) setDefault(
+	ctx context.Context,
+	kind string,
+	d *pulseDevice,
+) error {
+	return pactl(ctx, "set-default-"+kind, d.name)
+}
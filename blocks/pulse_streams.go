@@ -0,0 +1,225 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+const (
+	pulseStreamPlayback  = "playback"
+	pulseStreamRecording = "recording"
+)
+
+// Displays applications which are playing or recording audio, one block per
+// stream. Blocks of recording streams are urgent, so the bar indicates that
+// microphone is in use. Scrolling a block changes volume of the stream,
+// which requires `pactl`
+type PulseStreamsConfig struct {
+	Format *ConfigFormat `yaml:"format"`
+	// Which streams to show: `all`, `playback` or `recording`
+	Show string `yaml:"show"`
+	// Also show paused streams
+	ShowCorked bool `yaml:"show_corked"`
+	// Icons for `{icon}`, keyed by application name or binary
+	Icons map[string]string `yaml:"icons"`
+	// Icons used if there's no icon for the application, keyed by
+	// `playback` or `recording`
+	DefaultIcons map[string]string `yaml:"default_icons"`
+	// Volume step in percents
+	Step int `yaml:"step"`
+	// Glob patterns of application names or binaries to hide. Defaults to
+	// the level meters of pavucontrol
+	Ignore []string `yaml:"ignore"`
+}
+
+type pulseStream struct {
+	// `sink-input` or `source-output`
+	kind   string
+	index  uint32
+	app    string
+	binary string
+	media  string
+	volume int
+	corked bool
+}
+
+func (s *pulseStream) instance() string {
+	return fmt.Sprintf("%s:%d", s.kind, s.index)
+}
+
+func (s *pulseStream) isRecording() bool {
+	return s.kind == "source-output"
+}
+
+type PulseStreams struct {
+	PulseStreamsConfig
+	server  *pulseServer
+	streams []pulseStream
+}
+
+func NewPulseStreamsBlock() I3barBlocklet {
+	b := PulseStreams{}
+	b.Format = NewConfigFormatFromString("{icon}{app}")
+	b.Show = "all"
+	b.Step = 5
+	b.Ignore = []string{"pavucontrol*", "PulseAudio Volume Control"}
+	return &b
+}
+
+func (t *PulseStreams) GetConfig() interface{} {
+	return &t.PulseStreamsConfig
+}
+
+func (t *PulseStreams) addStream(
+	streams []pulseStream,
+	s pulseStream,
+	props map[string]string,
+	volume []uint32,
+) []pulseStream {
+	s.app = props["application.name"]
+	s.binary = props["application.process.binary"]
+	s.media = props["media.name"]
+	var max uint32
+	for _, v := range volume {
+		if v > max {
+			max = v
+		}
+	}
+	s.volume = volumeToPercentage(max)
+	if s.app == "" {
+		s.app = s.binary
+	}
+	if matchesAnyPattern(t.Ignore, s.app, s.binary) {
+		return streams
+	}
+	if s.corked && !t.ShowCorked {
+		return streams
+	}
+	return append(streams, s)
+}
+
+func (t *PulseStreams) fetchStreams() error {
+	streams := []pulseStream{}
+	if t.Show != pulseStreamPlayback {
+		outputs, err := t.server.client.SourceOutputs()
+		if err != nil {
+			return err
+		}
+		for _, o := range outputs {
+			streams = t.addStream(streams, pulseStream{
+				kind:   "source-output",
+				index:  o.Index,
+				corked: o.Corked,
+			}, o.PropList, o.Cvolume)
+		}
+	}
+	if t.Show != pulseStreamRecording {
+		inputs, err := t.server.client.SinkInputs()
+		if err != nil {
+			return err
+		}
+		for _, i := range inputs {
+			streams = t.addStream(streams, pulseStream{
+				kind:   "sink-input",
+				index:  i.Index,
+				corked: i.Corked,
+			}, i.PropList, i.Cvolume)
+		}
+	}
+	// Recording streams go first
+	sort.SliceStable(streams, func(i, j int) bool {
+		if streams[i].isRecording() != streams[j].isRecording() {
+			return streams[i].isRecording()
+		}
+		return streams[i].index < streams[j].index
+	})
+	t.streams = streams
+	return nil
+}
+
+func (t *PulseStreams) Run(ch UpdateChan, ctx context.Context) {
+	server, upd, err := newPulseServer()
+	if err != nil {
+		Log.Print(err)
+		return
+	}
+	defer server.Close()
+	t.server = server
+	throttleTimer := time.NewTimer(0)
+	for {
+		select {
+		case _, ok := <-upd:
+			if !ok {
+				Log.Print("pulse backend has exited")
+				return
+			}
+			throttleTimer.Reset(throttleDuration)
+		case <-throttleTimer.C:
+			if err := t.fetchStreams(); err != nil {
+				Log.Print(err)
+				continue
+			}
+			ch.SendUpdate()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *PulseStreams) OnEvent(e *I3barClickEvent, ctx context.Context) {
+	var delta string
+	switch e.Button {
+	case ButtonScrollUp:
+		delta = fmt.Sprintf("+%d%%", t.Step)
+	case ButtonScrollDown:
+		delta = fmt.Sprintf("-%d%%", t.Step)
+	default:
+		return
+	}
+	kind, index, found := strings.Cut(e.Instance, ":")
+	if !found {
+		return
+	}
+	if err := pactl(ctx, "set-"+kind+"-volume", index, delta); err != nil {
+		Log.Print(err)
+	}
+}
+
+func (t *PulseStreams) Render(cfg *AppConfig) []I3barBlock {
+	blocks := make([]I3barBlock, 0, len(t.streams))
+	for _, s := range t.streams {
+		kind := pulseStreamPlayback
+		if s.isRecording() {
+			kind = pulseStreamRecording
+		}
+		icon, ok := t.Icons[s.app]
+		if !ok {
+			if icon, ok = t.Icons[s.binary]; !ok {
+				icon = t.DefaultIcons[kind]
+			}
+		}
+		blocks = append(blocks, I3barBlock{
+			FullText: t.Format.Expand(formatting.NamedArgs{
+				"icon":   icon,
+				"app":    s.app,
+				"binary": s.binary,
+				"media":  s.media,
+				"kind":   kind,
+				"volume": s.volume,
+			}),
+			Instance: s.instance(),
+			Urgent:   s.isRecording(),
+		})
+	}
+	return blocks
+}
+
+func init() {
+	RegisterBlocklet("pulse_streams", NewPulseStreamsBlock)
+}
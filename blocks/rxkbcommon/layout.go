@@ -0,0 +1,36 @@
+package rxkbcommon
+
+type Layout struct {
+	Name        string
+	Variant     string
+	Brief       string
+	Description string
+	// ISO 3166 country codes
+	Countries []string
+	// ISO 639 language codes
+	Languages []string
+}
+
+// Layout name with variant, i.e. `us(dvorak)`
+func (l *Layout) ShortName() string {
+	if l.Variant == "" {
+		return l.Name
+	}
+	return l.Name + "(" + l.Variant + ")"
+}
+
+// The first country code of the layout, if any
+func (l *Layout) Country() string {
+	if len(l.Countries) > 0 {
+		return l.Countries[0]
+	}
+	return ""
+}
+
+// The first language code of the layout, if any
+func (l *Layout) Language() string {
+	if len(l.Languages) > 0 {
+		return l.Languages[0]
+	}
+	return ""
+}
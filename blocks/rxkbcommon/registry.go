@@ -0,0 +1,144 @@
+//go:build !xkbregistry
+
+package rxkbcommon
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const ruleset = "evdev"
+
+type xmlConfigItem struct {
+	Name             string   `xml:"name"`
+	ShortDescription string   `xml:"shortDescription"`
+	Description      string   `xml:"description"`
+	Countries        []string `xml:"countryList>iso3166Id"`
+	Languages        []string `xml:"languageList>iso639Id"`
+}
+
+type xmlLayout struct {
+	ConfigItem xmlConfigItem   `xml:"configItem"`
+	Variants   []xmlConfigItem `xml:"variantList>variant>configItem"`
+}
+
+type xmlRegistry struct {
+	Layouts []xmlLayout `xml:"layoutList>layout"`
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   map[string]Layout
+)
+
+// Same lookup order as `rxkb_context_include_path_append_default`
+func includePaths() []string {
+	paths := []string{}
+	home, _ := os.UserHomeDir()
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "xkb"))
+	} else if home != "" {
+		paths = append(paths, filepath.Join(home, ".config", "xkb"))
+	}
+	if home != "" {
+		paths = append(paths, filepath.Join(home, ".xkb"))
+	}
+	if extra := os.Getenv("XKB_CONFIG_EXTRA_PATH"); extra != "" {
+		paths = append(paths, extra)
+	} else {
+		paths = append(paths, "/etc/xkb")
+	}
+	if root := os.Getenv("XKB_CONFIG_ROOT"); root != "" {
+		paths = append(paths, root)
+	} else {
+		paths = append(paths, "/usr/share/X11/xkb")
+	}
+	return paths
+}
+
+func parseRegistry(r io.Reader, m map[string]Layout) error {
+	reg := xmlRegistry{}
+	if err := xml.NewDecoder(r).Decode(&reg); err != nil {
+		return err
+	}
+	add := func(l Layout) {
+		// Layouts from preceding include paths take precedence
+		if _, ok := m[l.Description]; !ok {
+			m[l.Description] = l
+		}
+	}
+	for _, xl := range reg.Layouts {
+		item := xl.ConfigItem
+		add(Layout{
+			Name:        item.Name,
+			Brief:       item.ShortDescription,
+			Description: item.Description,
+			Countries:   item.Countries,
+			Languages:   item.Languages,
+		})
+		for _, v := range xl.Variants {
+			l := Layout{
+				Name:        item.Name,
+				Variant:     v.Name,
+				Brief:       v.ShortDescription,
+				Description: v.Description,
+				Countries:   v.Countries,
+				Languages:   v.Languages,
+			}
+			// Variants inherit missing fields from their layout
+			if l.Brief == "" {
+				l.Brief = item.ShortDescription
+			}
+			if len(l.Countries) == 0 {
+				l.Countries = item.Countries
+			}
+			if len(l.Languages) == 0 {
+				l.Languages = item.Languages
+			}
+			add(l)
+		}
+	}
+	return nil
+}
+
+// Loads the base registry and the exotic layouts from `evdev.extras.xml`.
+// Unparsable files are skipped, the first error is returned along with the
+// layouts found in the rest of the files
+func loadLayouts() (map[string]Layout, error) {
+	m := map[string]Layout{}
+	var firstErr error
+	for _, p := range includePaths() {
+		for _, name := range []string{ruleset + ".xml", ruleset + ".extras.xml"} {
+			filename := filepath.Join(p, "rules", name)
+			f, err := os.Open(filename)
+			if err != nil {
+				continue
+			}
+			err = parseRegistry(f, m)
+			f.Close()
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", filename, err)
+			}
+		}
+	}
+	return m, firstErr
+}
+
+// Returns layouts, including variants, keyed by their description.
+// The registry is cached once it has been parsed without errors
+func GetLayouts() (map[string]Layout, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cache != nil {
+		return cache, nil
+	}
+	m, err := loadLayouts()
+	if err == nil {
+		cache = m
+	}
+	return m, err
+}
@@ -0,0 +1,92 @@
+//go:build !xkbregistry
+
+package rxkbcommon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testRegistry = `<?xml version="1.0" encoding="UTF-8"?>
+<xkbConfigRegistry version="1.1">
+  <layoutList>
+    <layout>
+      <configItem>
+        <name>us</name>
+        <shortDescription>en</shortDescription>
+        <description>English (US)</description>
+        <countryList>
+          <iso3166Id>US</iso3166Id>
+        </countryList>
+        <languageList>
+          <iso639Id>eng</iso639Id>
+        </languageList>
+      </configItem>
+      <variantList>
+        <variant>
+          <configItem>
+            <name>dvorak</name>
+            <description>English (Dvorak)</description>
+          </configItem>
+        </variant>
+      </variantList>
+    </layout>
+  </layoutList>
+</xkbConfigRegistry>`
+
+func TestParseRegistry(t *testing.T) {
+	m := map[string]Layout{}
+	if err := parseRegistry(strings.NewReader(testRegistry), m); err != nil {
+		t.Fatal(err)
+	}
+	us, ok := m["English (US)"]
+	if !ok || us.ShortName() != "us" || us.Country() != "US" {
+		t.Errorf("Unexpected layout: %+v", us)
+	}
+	dvorak, ok := m["English (Dvorak)"]
+	if !ok || dvorak.ShortName() != "us(dvorak)" {
+		t.Errorf("Unexpected variant: %+v", dvorak)
+	}
+	if dvorak.Brief != "en" || dvorak.Language() != "eng" {
+		t.Errorf("Variant should inherit layout fields: %+v", dvorak)
+	}
+}
+
+func TestLoadLayouts(t *testing.T) {
+	root := t.TempDir()
+	extra := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XKB_CONFIG_EXTRA_PATH", extra)
+	t.Setenv("XKB_CONFIG_ROOT", root)
+	write := func(dir, name, content string) {
+		p := filepath.Join(dir, "rules", name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(root, "evdev.xml", testRegistry)
+	write(root, "evdev.extras.xml", `<xkbConfigRegistry><layoutList><layout>
+<configItem><name>apl</name><description>APL</description></configItem>
+</layout></layoutList></xkbConfigRegistry>`)
+	write(extra, "evdev.xml", "<xkbConfigRegistry>")
+	m, err := loadLayouts()
+	if err == nil {
+		t.Error("Expected an error for the broken registry")
+	}
+	if _, ok := m["English (US)"]; !ok {
+		t.Error("Expected layouts from evdev.xml")
+	}
+	apl, ok := m["APL"]
+	if !ok {
+		t.Fatal("Expected layouts from evdev.extras.xml")
+	}
+	if apl.Country() != "" {
+		t.Errorf(`Expected no country, got "%s"`, apl.Country())
+	}
+}
@@ -0,0 +1,93 @@
+//go:build xkbregistry
+
+package rxkbcommon
+
+import "errors"
+
+/*
+#cgo LDFLAGS: -lxkbregistry
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <xkbcommon/xkbregistry.h>
+
+// Exotic layouts live in evdev.extras.xml, which is skipped by default
+void *init_ctx() {
+	struct rxkb_context *ctx = rxkb_context_new(
+		RXKB_CONTEXT_NO_DEFAULT_INCLUDES | RXKB_CONTEXT_LOAD_EXOTIC_LAYOUTS);
+	if (!ctx) {
+		return NULL;
+	}
+	if (!rxkb_context_include_path_append_default(ctx) ||
+		!rxkb_context_parse_default_ruleset(ctx)) {
+		rxkb_context_unref(ctx);
+		return NULL;
+	}
+	return ctx;
+}
+
+void deinit_ctx(void *ctx) {
+	rxkb_context_unref(ctx);
+}
+
+void *next_layout(void *ctx, void *layout) {
+	return layout ? rxkb_layout_next(layout) : rxkb_layout_first(ctx);
+}
+
+const char *get_name(void *lay) {
+	return rxkb_layout_get_name(lay);
+}
+
+const char *get_desc(void *lay) {
+	return rxkb_layout_get_description(lay);
+}
+
+const char *get_variant(void *lay) {
+	return rxkb_layout_get_variant(lay);
+}
+
+const char *get_brief(void *lay) {
+	return rxkb_layout_get_brief(lay);
+}
+
+const char *get_country(void *lay) {
+	struct rxkb_iso3166_code *code = rxkb_layout_get_iso3166_first(lay);
+	return code ? rxkb_iso3166_code_get_code(code) : NULL;
+}
+
+const char *get_language(void *lay) {
+	struct rxkb_iso639_code *code = rxkb_layout_get_iso639_first(lay);
+	return code ? rxkb_iso639_code_get_code(code) : NULL;
+}
+*/
+import "C"
+
+// Returns layouts, including variants, keyed by their description.
+// This implementation uses libxkbregistry and is enabled by the
+// `xkbregistry` build tag
+func GetLayouts() (map[string]Layout, error) {
+	m := map[string]Layout{}
+	ctx := C.init_ctx()
+	if ctx == nil {
+		return nil, errors.New("failed to parse the xkb registry")
+	}
+	defer C.deinit_ctx(ctx)
+	layout := C.next_layout(ctx, nil)
+	for layout != nil {
+		l := Layout{
+			Name:        C.GoString(C.get_name(layout)),
+			Variant:     C.GoString(C.get_variant(layout)),
+			Brief:       C.GoString(C.get_brief(layout)),
+			Description: C.GoString(C.get_desc(layout)),
+		}
+		if c := C.get_country(layout); c != nil {
+			l.Countries = []string{C.GoString(c)}
+		}
+		if c := C.get_language(layout); c != nil {
+			l.Languages = []string{C.GoString(c)}
+		}
+		m[l.Description] = l
+		layout = C.next_layout(ctx, layout)
+	}
+	return m, nil
+}
@@ -0,0 +1,201 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kraftwerk28/gost/blocks/ipc"
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+const (
+	swayInputActionEvents        = "events"
+	swayInputActionTap           = "tap"
+	swayInputActionNaturalScroll = "natural_scroll"
+)
+
+// Displays and toggles libinput settings of sway input devices.
+// Available actions: `events`, `tap`, `natural_scroll`
+type SwayInputConfig struct {
+	Format *ConfigFormat `yaml:"format"`
+	// Device identifier. If empty, all devices of `type` are shown
+	Input string `yaml:"input"`
+	// Device type, i.e. `touchpad`, `pointer`
+	Type string `yaml:"type"`
+	// Icons for `{icon}`, keyed by `send_events` state
+	Icons   map[string]string   `yaml:"icons"`
+	Actions ConfigButtonActions `yaml:"actions"`
+}
+
+type SwayInput struct {
+	SwayInputConfig
+	devices []ipc.IpcInputDevice
+	ipc     *ipc.IpcClient
+}
+
+func NewSwayInputBlock() I3barBlocklet {
+	b := SwayInput{}
+	b.Format = NewConfigFormatFromString("{icon$}{name}")
+	b.Type = "touchpad"
+	b.Actions = ConfigButtonActions{
+		"left":   swayInputActionEvents,
+		"right":  swayInputActionTap,
+		"middle": swayInputActionNaturalScroll,
+	}
+	return &b
+}
+
+func (s *SwayInput) GetConfig() interface{} {
+	return &s.SwayInputConfig
+}
+
+func (s *SwayInput) matches(device *ipc.IpcInputDevice) bool {
+	if device.Libinput == nil {
+		return false
+	}
+	if s.Input != "" {
+		return device.Identifier == s.Input
+	}
+	return device.Type == s.Type
+}
+
+func (s *SwayInput) processDevice(change string, device *ipc.IpcInputDevice) {
+	if !s.matches(device) {
+		return
+	}
+	for i := range s.devices {
+		if s.devices[i].Identifier == device.Identifier {
+			if change == "removed" {
+				s.devices = append(s.devices[:i], s.devices[i+1:]...)
+			} else {
+				s.devices[i] = *device
+			}
+			return
+		}
+	}
+	if change != "removed" {
+		s.devices = append(s.devices, *device)
+	}
+}
+
+func (s *SwayInput) Run(ch UpdateChan, ctx context.Context) {
+	ipcClient, err := ipc.NewIpcClient()
+	if err != nil {
+		Log.Print(err)
+		return
+	}
+	defer ipcClient.Close()
+	s.ipc = ipcClient
+
+	ipcClient.SendRaw(ipc.IpcMsgTypeGetInputs, nil)
+	_, res, err := ipcClient.Recv()
+	if err != nil {
+		Log.Print(err)
+		return
+	}
+	if devices, ok := res.(*[]ipc.IpcInputDevice); ok {
+		for i := range *devices {
+			s.processDevice("added", &(*devices)[i])
+		}
+	}
+
+	ipcClient.Send(ipc.IpcMsgTypeSubscribe, []string{"input"})
+	if _, _, err = ipcClient.Recv(); err != nil {
+		return
+	}
+
+	ch.SendUpdate()
+
+	type IpcChanValue struct {
+		typ     ipc.IpcMsgType
+		payload interface{}
+	}
+	evc := make(chan IpcChanValue)
+	go func() {
+		for {
+			if t, d, err := ipcClient.Recv(); err == nil {
+				evc <- IpcChanValue{t, d}
+			} else {
+				break
+			}
+		}
+	}()
+	throttleTimer := time.NewTimer(throttleDuration)
+	for {
+		select {
+		case <-throttleTimer.C:
+			ch.SendUpdate()
+		case e := <-evc:
+			if c, ok := e.payload.(*ipc.InputChange); ok {
+				s.processDevice(c.Change, &c.Input)
+				throttleTimer.Reset(throttleDuration)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func toggleLibinputState(state string) string {
+	if state == "enabled" {
+		return "disabled"
+	}
+	return "enabled"
+}
+
+func (s *SwayInput) OnEvent(e *I3barClickEvent, ctx context.Context) {
+	if s.ipc == nil {
+		return
+	}
+	var device *ipc.IpcInputDevice
+	for i := range s.devices {
+		if s.devices[i].Identifier == e.Instance {
+			device = &s.devices[i]
+			break
+		}
+	}
+	if device == nil {
+		return
+	}
+	li := device.Libinput
+	var setting, value string
+	switch s.Actions.Get(e.Button) {
+	case swayInputActionEvents:
+		setting, value = "events", toggleLibinputState(li.SendEvents)
+	case swayInputActionTap:
+		setting, value = "tap", toggleLibinputState(li.Tap)
+	case swayInputActionNaturalScroll:
+		setting, value = "natural_scroll", toggleLibinputState(li.NaturalScroll)
+	default:
+		return
+	}
+	cmd := fmt.Sprintf("input %q %s %s", device.Identifier, setting, value)
+	if err := s.ipc.SendRaw(ipc.IpcMsgTypeCommand, []byte(cmd)); err != nil {
+		Log.Print(err)
+	}
+}
+
+func (s *SwayInput) Render(cfg *AppConfig) []I3barBlock {
+	blocks := make([]I3barBlock, 0, len(s.devices))
+	for _, d := range s.devices {
+		li := d.Libinput
+		blocks = append(blocks, I3barBlock{
+			FullText: s.Format.Expand(formatting.NamedArgs{
+				"name":           d.Name,
+				"identifier":     d.Identifier,
+				"icon":           s.Icons[li.SendEvents],
+				"events":         li.SendEvents,
+				"tap":            li.Tap,
+				"natural_scroll": li.NaturalScroll,
+			}),
+			Instance: d.Identifier,
+		})
+	}
+	return blocks
+}
+
+func init() {
+	RegisterBlocklet("sway_input", NewSwayInputBlock)
+}
@@ -3,6 +3,7 @@ package blocks
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/kraftwerk28/gost/blocks/ipc"
@@ -16,14 +17,44 @@ import (
 type SwayLayoutConfig struct {
 	Format *ConfigFormat `yaml:"format"`
 	Input  *string       `yaml:"input"`
+	// Remember layout per window and restore it when the window is focused
+	RememberLayout bool `yaml:"remember_layout"`
+	// Layout (long or short name) to use for new windows of given app_id.
+	// Takes precedence over the layout last used in the app
+	DefaultLayouts map[string]string `yaml:"default_layouts"`
+	// Custom `{label}`s keyed by long or short layout name
+	Labels map[string]string `yaml:"labels"`
+	// Custom `{flag}`s keyed by long or short layout name
+	Flags map[string]string `yaml:"flags"`
+	// Show a block per keyboard if keyboards have different layouts active
+	PerKeyboard bool `yaml:"per_keyboard"`
+	// Keyboard identifiers to show when `per_keyboard` is enabled
+	Keyboards []string `yaml:"keyboards"`
+}
+
+type swayKeyboard struct {
+	identifier, name string
+	layouts          []string
+	index            int
+}
+
+func (k *swayKeyboard) activeLayout() string {
+	if k.index < 0 || k.index >= len(k.layouts) {
+		return ""
+	}
+	return k.layouts[k.index]
 }
 
 type SwayLayout struct {
 	SwayLayoutConfig
-	layoutLongToShort  map[string]string
+	layoutInfo         map[string]rxkbcommon.Layout
 	layouts            []string
 	currentLayoutIndex int
+	keyboards          []*swayKeyboard
 	ipc                *ipc.IpcClient
+	focusedWindow      *ipc.IpcContainer
+	windowLayouts      map[int64]int
+	appLayouts         map[string]int
 }
 
 func NewSwayLayoutBlock() I3barBlocklet {
@@ -34,17 +65,135 @@ func (s *SwayLayout) GetConfig() interface{} {
 	return &s.SwayLayoutConfig
 }
 
+func (s *SwayLayout) findKeyboard(identifier string) int {
+	for i, k := range s.keyboards {
+		if k.identifier == identifier {
+			return i
+		}
+	}
+	return -1
+}
+
+// The first matching keyboard is the primary one: its layout is displayed
+// unless `per_keyboard` is on and keyboards differ
+func (s *SwayLayout) updatePrimary() {
+	if len(s.keyboards) == 0 {
+		s.layouts = nil
+		s.currentLayoutIndex = 0
+		return
+	}
+	s.layouts = s.keyboards[0].layouts
+	s.currentLayoutIndex = s.keyboards[0].index
+}
+
 func (s *SwayLayout) processDevice(device *ipc.IpcInputDevice) bool {
 	if s.Input != nil && device.Identifier != *s.Input || device.Type != "keyboard" {
 		return false
 	}
-	s.currentLayoutIndex = device.XkbActiveLayoutIndex
-	s.layouts = device.XkbLayoutNames
-	return true
+	i := s.findKeyboard(device.Identifier)
+	if i == -1 {
+		s.keyboards = append(s.keyboards, &swayKeyboard{
+			identifier: device.Identifier,
+		})
+		i = len(s.keyboards) - 1
+	}
+	k := s.keyboards[i]
+	k.name = device.Name
+	k.layouts = device.XkbLayoutNames
+	k.index = device.XkbActiveLayoutIndex
+	s.updatePrimary()
+	return i == 0
+}
+
+func (s *SwayLayout) removeDevice(device *ipc.IpcInputDevice) {
+	if i := s.findKeyboard(device.Identifier); i != -1 {
+		s.keyboards = append(s.keyboards[:i], s.keyboards[i+1:]...)
+		s.updatePrimary()
+	}
+}
+
+func (s *SwayLayout) findLayoutIndex(name string) int {
+	for i, l := range s.layouts {
+		info := s.layoutInfo[l]
+		if l == name || info.Name == name || info.ShortName() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *SwayLayout) inputTarget(identifier string) string {
+	if identifier != "" {
+		return fmt.Sprintf("%q", identifier)
+	}
+	if s.Input != nil {
+		return fmt.Sprintf("%q", *s.Input)
+	}
+	return "type:keyboard"
+}
+
+// `layout` is either layout index, `next` or `prev`
+func (s *SwayLayout) switchKeyboardLayout(identifier string, layout string) error {
+	cmd := fmt.Sprintf(
+		`input %s xkb_switch_layout %s`,
+		s.inputTarget(identifier), layout,
+	)
+	return s.ipc.SendRaw(ipc.IpcMsgTypeCommand, []byte(cmd))
+}
+
+func (s *SwayLayout) switchLayout(index int) error {
+	return s.switchKeyboardLayout("", strconv.Itoa(index))
+}
+
+func (s *SwayLayout) rememberLayout() {
+	if w := s.focusedWindow; w != nil {
+		s.windowLayouts[w.Id] = s.currentLayoutIndex
+		if w.AppId != "" {
+			s.appLayouts[w.AppId] = s.currentLayoutIndex
+		}
+	}
+}
+
+func (s *SwayLayout) processWindowChange(c *ipc.WindowChange) {
+	switch c.Change {
+	case "close":
+		delete(s.windowLayouts, c.Container.Id)
+		if s.focusedWindow != nil && s.focusedWindow.Id == c.Container.Id {
+			s.focusedWindow = nil
+		}
+	case "focus":
+		w := c.Container
+		s.focusedWindow = &w
+		index, ok := s.windowLayouts[w.Id]
+		if !ok {
+			if name, ok := s.DefaultLayouts[w.AppId]; ok {
+				index = s.findLayoutIndex(name)
+			} else if index, ok = s.appLayouts[w.AppId]; !ok {
+				index = -1
+			}
+		}
+		if index < 0 || index >= len(s.layouts) {
+			// Unknown window: adopt whatever layout is active now
+			s.rememberLayout()
+			return
+		}
+		if index != s.currentLayoutIndex {
+			if err := s.switchLayout(index); err != nil {
+				Log.Print(err)
+			}
+		}
+	}
 }
 
 func (s *SwayLayout) Run(ch UpdateChan, ctx context.Context) {
-	s.layoutLongToShort = rxkbcommon.GetLayoutShortNames()
+	s.windowLayouts = make(map[int64]int)
+	s.appLayouts = make(map[string]int)
+
+	layoutInfo, err := rxkbcommon.GetLayouts()
+	if err != nil {
+		Log.Print(err)
+	}
+	s.layoutInfo = layoutInfo
 
 	ipcClient, err := ipc.NewIpcClient()
 	// s.ipc, err := ipc.NewIpcClient()
@@ -67,12 +216,14 @@ func (s *SwayLayout) Run(ch UpdateChan, ctx context.Context) {
 		return
 	}
 	for _, dev := range *swayInputs.(*[]ipc.IpcInputDevice) {
-		if s.processDevice(&dev) {
-			break
-		}
+		s.processDevice(&dev)
 	}
 
-	ipcClient.Send(ipc.IpcMsgTypeSubscribe, []string{"input"})
+	events := []string{"input"}
+	if s.RememberLayout {
+		events = append(events, "window")
+	}
+	ipcClient.Send(ipc.IpcMsgTypeSubscribe, events)
 	if msgType, _, err = ipcClient.Recv(); err != nil {
 		return
 	}
@@ -102,8 +253,16 @@ func (s *SwayLayout) Run(ch UpdateChan, ctx context.Context) {
 			throttleTimer.Reset(throttleDuration)
 			switch e.typ {
 			case ipc.IpcEventTypeInput:
-				device := e.payload.(*ipc.InputChange).Input
-				s.processDevice(&device)
+				change := e.payload.(*ipc.InputChange)
+				if change.Change == "removed" {
+					s.removeDevice(&change.Input)
+				} else if s.processDevice(&change.Input) && s.RememberLayout {
+					s.rememberLayout()
+				}
+			case ipc.IpcEventTypeWindow:
+				if c, ok := e.payload.(*ipc.WindowChange); ok {
+					s.processWindowChange(c)
+				}
 			}
 		case <-ctx.Done():
 			return
@@ -112,28 +271,104 @@ func (s *SwayLayout) Run(ch UpdateChan, ctx context.Context) {
 }
 
 func (s *SwayLayout) OnEvent(e *I3barClickEvent, ctx context.Context) {
-	if e.Button == ButtonRight {
-		index := (s.currentLayoutIndex + 1) % len(s.layouts)
-		cmd := fmt.Sprintf(`input type:keyboard xkb_switch_layout %d`, index)
-		if err := s.ipc.SendRaw(ipc.IpcMsgTypeCommand, []byte(cmd)); err != nil {
-			Log.Print(err)
+	if s.layouts == nil {
+		return
+	}
+	var err error
+	switch e.Button {
+	case ButtonRight:
+		if e.Instance != "" {
+			err = s.switchKeyboardLayout(e.Instance, "next")
+		} else {
+			index := (s.currentLayoutIndex + 1) % len(s.layouts)
+			err = s.switchLayout(index)
+		}
+	case ButtonScrollUp:
+		err = s.switchKeyboardLayout(e.Instance, "next")
+	case ButtonScrollDown:
+		err = s.switchKeyboardLayout(e.Instance, "prev")
+	}
+	if err != nil {
+		Log.Print(err)
+	}
+}
+
+func (s *SwayLayout) layoutArgs(longName string) formatting.NamedArgs {
+	info, ok := s.layoutInfo[longName]
+	if !ok {
+		info = rxkbcommon.Layout{Name: longName, Description: longName}
+	}
+	shortName := info.ShortName()
+	label, ok := s.Labels[longName]
+	if !ok {
+		if label, ok = s.Labels[shortName]; !ok {
+			label = shortName
 		}
 	}
+	flag, ok := s.Flags[longName]
+	if !ok {
+		if flag, ok = s.Flags[shortName]; !ok {
+			flag = CountryFlagFromIsoCode(info.Country())
+		}
+	}
+	return formatting.NamedArgs{
+		"long":     longName,
+		"short":    shortName,
+		"layout":   info.Name,
+		"variant":  info.Variant,
+		"brief":    info.Brief,
+		"country":  info.Country(),
+		"language": info.Language(),
+		"label":    label,
+		"flag":     flag,
+	}
+}
+
+func (s *SwayLayout) shownKeyboards() []*swayKeyboard {
+	if len(s.Keyboards) == 0 {
+		return s.keyboards
+	}
+	result := []*swayKeyboard{}
+	for _, k := range s.keyboards {
+		for _, id := range s.Keyboards {
+			if k.identifier == id {
+				result = append(result, k)
+				break
+			}
+		}
+	}
+	return result
 }
 
 func (s *SwayLayout) Render(cfg *AppConfig) []I3barBlock {
-	if s.layouts == nil {
+	if s.layouts == nil || len(s.keyboards) == 0 {
 		return nil
 	}
-	currentLayout := s.layouts[s.currentLayoutIndex]
-	shortName := s.layoutLongToShort[currentLayout]
-	return []I3barBlock{{
-		FullText: s.Format.Expand(formatting.NamedArgs{
-			"long":  currentLayout,
-			"short": shortName,
-			"flag":  CountryFlagFromIsoCode(shortName),
-		}),
-	}}
+	if s.PerKeyboard {
+		keyboards := s.shownKeyboards()
+		differ := false
+		for _, k := range keyboards {
+			if k.activeLayout() != keyboards[0].activeLayout() {
+				differ = true
+				break
+			}
+		}
+		if differ {
+			blocks := make([]I3barBlock, 0, len(keyboards))
+			for _, k := range keyboards {
+				args := s.layoutArgs(k.activeLayout())
+				args["keyboard"] = k.identifier
+				args["device"] = k.name
+				blocks = append(blocks, I3barBlock{
+					FullText: s.Format.Expand(args),
+					Instance: k.identifier,
+				})
+			}
+			return blocks
+		}
+	}
+	args := s.layoutArgs(s.keyboards[0].activeLayout())
+	return []I3barBlock{{FullText: s.Format.Expand(args)}}
 }
 
 func init() {
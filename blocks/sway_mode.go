@@ -0,0 +1,234 @@
+package blocks
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kraftwerk28/gost/blocks/ipc"
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+const swayDefaultMode = "default"
+
+// Displays current sway binding mode. Hidden in the `default` mode.
+// Clicking the blocklet switches back to the `default` mode.
+type SwayModeConfig struct {
+	Format *ConfigFormat `yaml:"format"`
+	// Custom labels for modes, i.e. `resize: "  "`
+	Labels map[string]string `yaml:"labels"`
+	// Text colors for modes, i.e. `resize: "#ff8800"`
+	Colors map[string]*ConfigColor `yaml:"colors"`
+	// Parse the mode's `bindsym`s from sway config into `{bindings}`
+	ShowBindings     bool          `yaml:"show_bindings"`
+	BindingFormat    *ConfigFormat `yaml:"binding_format"`
+	BindingSeparator string        `yaml:"binding_separator"`
+}
+
+type swayBinding struct {
+	key, command string
+}
+
+type SwayMode struct {
+	SwayModeConfig
+	mode string
+	// The mode was declared with `--pango_markup`
+	pangoMarkup bool
+	bindings    map[string][]swayBinding
+	ipc         *ipc.IpcClient
+}
+
+func NewSwayModeBlock() I3barBlocklet {
+	b := SwayMode{}
+	b.Format = NewConfigFormatFromString("{mode}")
+	b.BindingFormat = NewConfigFormatFromString("{key}: {command}")
+	b.BindingSeparator = " | "
+	b.mode = swayDefaultMode
+	return &b
+}
+
+func (s *SwayMode) GetConfig() interface{} {
+	return &s.SwayModeConfig
+}
+
+// Extracts bindings of every `mode` block from sway config text.
+// Variables defined with `set` are expanded.
+func parseSwayModeBindings(config string) map[string][]swayBinding {
+	result := map[string][]swayBinding{}
+	vars := map[string]string{}
+	varNames := []string{}
+	expand := func(s string) string {
+		// Longest names first, so `$mod` won't clobber `$mod_alt`
+		for _, k := range varNames {
+			s = strings.ReplaceAll(s, k, vars[k])
+		}
+		return s
+	}
+	var currentMode string
+	sc := bufio.NewScanner(strings.NewReader(config))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch {
+		case fields[0] == "set" && len(fields) >= 3:
+			if _, ok := vars[fields[1]]; !ok {
+				varNames = append(varNames, fields[1])
+				sort.Slice(varNames, func(i, j int) bool {
+					return len(varNames[i]) > len(varNames[j])
+				})
+			}
+			vars[fields[1]] = expand(strings.Join(fields[2:], " "))
+		case fields[0] == "mode" && fields[len(fields)-1] == "{":
+			name := strings.Join(fields[1:len(fields)-1], " ")
+			name = strings.TrimSpace(strings.TrimPrefix(name, "--pango_markup"))
+			currentMode = strings.Trim(expand(name), `"'`)
+		case line == "}":
+			currentMode = ""
+		case currentMode != "" && fields[0] == "bindsym":
+			args := fields[1:]
+			for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+				args = args[1:]
+			}
+			if len(args) < 2 {
+				continue
+			}
+			result[currentMode] = append(result[currentMode], swayBinding{
+				key:     expand(args[0]),
+				command: expand(strings.Join(args[1:], " ")),
+			})
+		}
+	}
+	return result
+}
+
+func (s *SwayMode) loadBindings() error {
+	if err := s.ipc.SendRaw(ipc.IpcMsgTypeGetConfig, nil); err != nil {
+		return err
+	}
+	_, res, err := s.ipc.Recv()
+	if err != nil {
+		return err
+	}
+	if cfg, ok := res.(*ipc.IpcConfig); ok {
+		s.bindings = parseSwayModeBindings(cfg.Config)
+	}
+	return nil
+}
+
+func (s *SwayMode) Run(ch UpdateChan, ctx context.Context) {
+	ipcClient, err := ipc.NewIpcClient()
+	if err != nil {
+		Log.Print(err)
+		return
+	}
+	defer ipcClient.Close()
+	s.ipc = ipcClient
+
+	if s.ShowBindings {
+		if err := s.loadBindings(); err != nil {
+			Log.Print(err)
+		}
+	}
+
+	if err := ipcClient.SendRaw(ipc.IpcMsgTypeGetBindingState, nil); err != nil {
+		Log.Print(err)
+		return
+	}
+	_, res, err := ipcClient.Recv()
+	if err != nil {
+		Log.Print(err)
+		return
+	}
+	if st, ok := res.(*ipc.IpcBindingState); ok {
+		s.mode = st.Name
+	}
+
+	ipcClient.Send(ipc.IpcMsgTypeSubscribe, []string{"mode"})
+	if _, _, err = ipcClient.Recv(); err != nil {
+		return
+	}
+
+	ch.SendUpdate()
+
+	type IpcChanValue struct {
+		typ     ipc.IpcMsgType
+		payload interface{}
+	}
+	evc := make(chan IpcChanValue)
+	go func() {
+		for {
+			if t, d, err := ipcClient.Recv(); err == nil {
+				evc <- IpcChanValue{t, d}
+			} else {
+				break
+			}
+		}
+	}()
+	throttleTimer := time.NewTimer(throttleDuration)
+	for {
+		select {
+		case <-throttleTimer.C:
+			ch.SendUpdate()
+		case e := <-evc:
+			if m, ok := e.payload.(*ipc.ModeChange); ok {
+				s.mode = m.Change
+				s.pangoMarkup = m.PangoMarkup
+				throttleTimer.Reset(throttleDuration)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *SwayMode) OnEvent(e *I3barClickEvent, ctx context.Context) {
+	if s.ipc == nil {
+		return
+	}
+	cmd := []byte("mode " + swayDefaultMode)
+	if err := s.ipc.SendRaw(ipc.IpcMsgTypeCommand, cmd); err != nil {
+		Log.Print(err)
+	}
+}
+
+func (s *SwayMode) Render(cfg *AppConfig) []I3barBlock {
+	if s.mode == "" || s.mode == swayDefaultMode {
+		return nil
+	}
+	label, ok := s.Labels[s.mode]
+	if !ok {
+		label = s.mode
+	}
+	args := formatting.NamedArgs{
+		"mode": label,
+		"name": s.mode,
+	}
+	if s.ShowBindings {
+		parts := []string{}
+		for _, b := range s.bindings[s.mode] {
+			parts = append(parts, s.BindingFormat.Expand(formatting.NamedArgs{
+				"key":     b.key,
+				"command": b.command,
+			}))
+		}
+		args["bindings"] = strings.Join(parts, s.BindingSeparator)
+	}
+	b := I3barBlock{FullText: s.Format.Expand(args)}
+	if s.pangoMarkup {
+		b.Markup = MarkupPango
+	}
+	if c, ok := s.Colors[s.mode]; ok && c != nil {
+		b.Color = c.String()
+	}
+	return []I3barBlock{b}
+}
+
+func init() {
+	RegisterBlocklet("sway_mode", NewSwayModeBlock)
+}
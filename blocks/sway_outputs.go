@@ -0,0 +1,213 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kraftwerk28/gost/blocks/ipc"
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+// Displays sway outputs, one block per output.
+// Left click toggles DPMS, right click enables/disables the output,
+// scrolling cycles output scale through `scales`
+type SwayOutputsConfig struct {
+	Format *ConfigFormat `yaml:"format"`
+	// Also show disabled outputs, so they can be enabled back
+	ShowInactive bool `yaml:"show_inactive"`
+	// Scale values to cycle through in ascending order, i.e. `[1, 1.5, 2]`
+	Scales []float64         `yaml:"scales"`
+	Icons  map[string]string `yaml:"icons"`
+}
+
+type SwayOutputs struct {
+	SwayOutputsConfig
+	outputs []ipc.IpcOutput
+	ipc     *ipc.IpcClient
+}
+
+func NewSwayOutputsBlock() I3barBlocklet {
+	b := SwayOutputs{}
+	b.Format = NewConfigFormatFromString("{name} {mode} {scale}x")
+	b.Scales = []float64{1, 1.25, 1.5, 2}
+	return &b
+}
+
+func (s *SwayOutputs) GetConfig() interface{} {
+	return &s.SwayOutputsConfig
+}
+
+func (s *SwayOutputs) findOutput(name string) *ipc.IpcOutput {
+	for i := range s.outputs {
+		if s.outputs[i].Name == name {
+			return &s.outputs[i]
+		}
+	}
+	return nil
+}
+
+func (s *SwayOutputs) Run(ch UpdateChan, ctx context.Context) {
+	ipcClient, err := ipc.NewIpcClient()
+	if err != nil {
+		Log.Print(err)
+		return
+	}
+	defer ipcClient.Close()
+	s.ipc = ipcClient
+
+	ipcClient.Send(ipc.IpcMsgTypeSubscribe, []string{"output"})
+	if _, _, err = ipcClient.Recv(); err != nil {
+		Log.Print(err)
+		return
+	}
+
+	type IpcChanValue struct {
+		typ     ipc.IpcMsgType
+		payload interface{}
+	}
+	evc := make(chan IpcChanValue)
+	go func() {
+		for {
+			if t, d, err := ipcClient.Recv(); err == nil {
+				evc <- IpcChanValue{t, d}
+			} else {
+				break
+			}
+		}
+	}()
+	throttleTimer := time.NewTimer(0)
+	for {
+		select {
+		case <-throttleTimer.C:
+			if err := ipcClient.SendRaw(ipc.IpcMsgTypeGetOutputs, nil); err != nil {
+				Log.Print(err)
+				return
+			}
+		case e := <-evc:
+			switch p := e.payload.(type) {
+			case *ipc.OutputChange:
+				throttleTimer.Reset(throttleDuration)
+			case *[]ipc.IpcOutput:
+				s.outputs = *p
+				ch.SendUpdate()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func formatScale(scale float64) string {
+	return strconv.FormatFloat(scale, 'f', -1, 64)
+}
+
+// Returns the next (or previous) scale from `scales` relative to current one,
+// wrapping around
+func (s *SwayOutputs) nextScale(current float64, step int) float64 {
+	n := len(s.Scales)
+	if n == 0 {
+		return current
+	}
+	if step > 0 {
+		for _, sc := range s.Scales {
+			if sc > current {
+				return sc
+			}
+		}
+		return s.Scales[0]
+	}
+	for i := n - 1; i >= 0; i-- {
+		if s.Scales[i] < current {
+			return s.Scales[i]
+		}
+	}
+	return s.Scales[n-1]
+}
+
+func (s *SwayOutputs) OnEvent(e *I3barClickEvent, ctx context.Context) {
+	o := s.findOutput(e.Instance)
+	if s.ipc == nil || o == nil {
+		return
+	}
+	var cmd string
+	switch e.Button {
+	case ButtonLeft:
+		state := "on"
+		if o.IsPowered() {
+			state = "off"
+		}
+		if o.Power != nil {
+			cmd = fmt.Sprintf("output %q power %s", o.Name, state)
+		} else {
+			cmd = fmt.Sprintf("output %q dpms %s", o.Name, state)
+		}
+	case ButtonRight:
+		if o.Active {
+			cmd = fmt.Sprintf("output %q disable", o.Name)
+		} else {
+			cmd = fmt.Sprintf("output %q enable", o.Name)
+		}
+	case ButtonScrollUp, ButtonScrollDown:
+		if !o.Active {
+			return
+		}
+		step := 1
+		if e.Button == ButtonScrollDown {
+			step = -1
+		}
+		scale := s.nextScale(o.Scale, step)
+		cmd = fmt.Sprintf("output %q scale %s", o.Name, formatScale(scale))
+	default:
+		return
+	}
+	if err := s.ipc.SendRaw(ipc.IpcMsgTypeCommand, []byte(cmd)); err != nil {
+		Log.Print(err)
+	}
+}
+
+func (s *SwayOutputs) Render(cfg *AppConfig) []I3barBlock {
+	blocks := make([]I3barBlock, 0, len(s.outputs))
+	for _, o := range s.outputs {
+		if !o.Active && !s.ShowInactive {
+			continue
+		}
+		var state string
+		switch {
+		case !o.Active:
+			state = "disabled"
+		case o.IsPowered():
+			state = "on"
+		default:
+			state = "off"
+		}
+		mode := ""
+		if o.Active {
+			mode = fmt.Sprintf(
+				"%dx%d@%dHz",
+				o.CurrentMode.Width,
+				o.CurrentMode.Height,
+				(o.CurrentMode.Refresh+500)/1000,
+			)
+		}
+		blocks = append(blocks, I3barBlock{
+			FullText: s.Format.Expand(formatting.NamedArgs{
+				"name":  o.Name,
+				"make":  o.Make,
+				"model": o.Model,
+				"mode":  mode,
+				"scale": formatScale(o.Scale),
+				"dpms":  state,
+				"icon":  s.Icons[state],
+			}),
+			Instance: o.Name,
+		})
+	}
+	return blocks
+}
+
+func init() {
+	RegisterBlocklet("sway_outputs", NewSwayOutputsBlock)
+}
@@ -0,0 +1,196 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kraftwerk28/gost/blocks/ipc"
+	. "github.com/kraftwerk28/gost/core"
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+const swayScratchpadWorkspace = "__i3_scratch"
+
+// Displays the number of windows in sway's scratchpad and urgent windows.
+// Left click shows the scratchpad, right click focuses the most recent urgent
+// window
+type SwayScratchpadConfig struct {
+	Format *ConfigFormat `yaml:"format"`
+	// Separator for `{urgent_titles}`
+	Separator string `yaml:"separator"`
+	// Hide the blocklet if scratchpad is empty and nothing is urgent
+	HideEmpty bool `yaml:"hide_empty"`
+}
+
+type SwayScratchpad struct {
+	SwayScratchpadConfig
+	// Guards `count` and `urgent`, which are read by OnEvent and Render
+	mu    sync.Mutex
+	count int
+	// Urgent windows, the most recent is the last one
+	urgent []ipc.IpcContainer
+	ipc    *ipc.IpcClient
+}
+
+func NewSwayScratchpadBlock() I3barBlocklet {
+	b := SwayScratchpad{}
+	b.Format = NewConfigFormatFromString("{count}")
+	b.Separator = ", "
+	b.HideEmpty = true
+	return &b
+}
+
+func (s *SwayScratchpad) GetConfig() interface{} {
+	return &s.SwayScratchpadConfig
+}
+
+func (s *SwayScratchpad) processTree(root *ipc.IpcNode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count = 0
+	scratch := root.Find(func(n *ipc.IpcNode) bool {
+		return n.Type == "workspace" && n.Name == swayScratchpadWorkspace
+	})
+	if scratch != nil {
+		scratch.Windows(func(*ipc.IpcNode) { s.count++ })
+	}
+	urgentNow := map[int64]ipc.IpcContainer{}
+	root.Windows(func(n *ipc.IpcNode) {
+		if n.Urgent {
+			urgentNow[n.Id] = n.IpcContainer
+		}
+	})
+	// Keep the order in which windows became urgent
+	urgent := []ipc.IpcContainer{}
+	for _, w := range s.urgent {
+		if c, ok := urgentNow[w.Id]; ok {
+			urgent = append(urgent, c)
+			delete(urgentNow, w.Id)
+		}
+	}
+	for _, c := range urgentNow {
+		urgent = append(urgent, c)
+	}
+	s.urgent = urgent
+}
+
+func (s *SwayScratchpad) processWindowChange(c *ipc.WindowChange) {
+	if c.Change != "urgent" || !c.Container.Urgent {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Move the window to the end, so it is the most recent one
+	for i, w := range s.urgent {
+		if w.Id == c.Container.Id {
+			s.urgent = append(s.urgent[:i], s.urgent[i+1:]...)
+			break
+		}
+	}
+	s.urgent = append(s.urgent, c.Container)
+}
+
+func (s *SwayScratchpad) Run(ch UpdateChan, ctx context.Context) {
+	ipcClient, err := ipc.NewIpcClient()
+	if err != nil {
+		Log.Print(err)
+		return
+	}
+	defer ipcClient.Close()
+	s.ipc = ipcClient
+
+	ipcClient.Send(ipc.IpcMsgTypeSubscribe, []string{"window"})
+	if _, _, err = ipcClient.Recv(); err != nil {
+		Log.Print(err)
+		return
+	}
+
+	type IpcChanValue struct {
+		typ     ipc.IpcMsgType
+		payload interface{}
+	}
+	evc := make(chan IpcChanValue)
+	go func() {
+		for {
+			if t, d, err := ipcClient.Recv(); err == nil {
+				evc <- IpcChanValue{t, d}
+			} else {
+				break
+			}
+		}
+	}()
+	// The tree is re-fetched once per burst of window events. The response
+	// is read by the goroutine above, along with the events
+	throttleTimer := time.NewTimer(0)
+	for {
+		select {
+		case <-throttleTimer.C:
+			if err := ipcClient.SendRaw(ipc.IpcMsgTypeGetTree, nil); err != nil {
+				Log.Print(err)
+				return
+			}
+		case e := <-evc:
+			switch p := e.payload.(type) {
+			case *ipc.WindowChange:
+				s.processWindowChange(p)
+				throttleTimer.Reset(throttleDuration)
+			case *ipc.IpcNode:
+				s.processTree(p)
+				ch.SendUpdate()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *SwayScratchpad) OnEvent(e *I3barClickEvent, ctx context.Context) {
+	if s.ipc == nil {
+		return
+	}
+	var cmd string
+	switch e.Button {
+	case ButtonLeft:
+		cmd = "scratchpad show"
+	case ButtonRight:
+		s.mu.Lock()
+		if len(s.urgent) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		cmd = fmt.Sprintf("[con_id=%d] focus", s.urgent[len(s.urgent)-1].Id)
+		s.mu.Unlock()
+	default:
+		return
+	}
+	if err := s.ipc.SendRaw(ipc.IpcMsgTypeCommand, []byte(cmd)); err != nil {
+		Log.Print(err)
+	}
+}
+
+func (s *SwayScratchpad) Render(cfg *AppConfig) []I3barBlock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.HideEmpty && s.count == 0 && len(s.urgent) == 0 {
+		return nil
+	}
+	titles := make([]string, len(s.urgent))
+	for i, w := range s.urgent {
+		titles[i] = w.Name
+	}
+	return []I3barBlock{{
+		FullText: s.Format.Expand(formatting.NamedArgs{
+			"count":         s.count,
+			"urgent_count":  len(s.urgent),
+			"urgent_titles": strings.Join(titles, s.Separator),
+		}),
+		Urgent: len(s.urgent) > 0,
+	}}
+}
+
+func init() {
+	RegisterBlocklet("sway_scratchpad", NewSwayScratchpadBlock)
+}
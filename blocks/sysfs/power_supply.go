@@ -0,0 +1,123 @@
+package sysfs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const PowerSupplyRoot = "/sys/class/power_supply"
+
+const (
+	PowerSupplyTypeBattery = "Battery"
+	PowerSupplyTypeMains   = "Mains"
+)
+
+// A device from /sys/class/power_supply.
+// Energy is in Wh, power in W
+type PowerSupply struct {
+	Name             string
+	Type             string
+	Scope            string
+	ModelName        string
+	Status           string
+	Online           bool
+	Capacity         float64
+	EnergyNow        float64
+	EnergyFull       float64
+	EnergyFullDesign float64
+	PowerNow         float64
+	hasCapacity      bool
+	hasEnergy        bool
+}
+
+// Whether the battery powers the system (as opposed to peripherals like
+// mice or keyboards, which have `Device` scope)
+func (p *PowerSupply) IsSystem() bool {
+	return p.Scope != "Device"
+}
+
+func readString(dir, name string) string {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func readNumber(dir, name string) (float64, bool) {
+	s := readString(dir, name)
+	if s == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// Reads a value in micro-units (µWh, µAh, µW, µA, µV)
+func readMicro(dir, name string) (float64, bool) {
+	n, ok := readNumber(dir, name)
+	return n / 1e6, ok
+}
+
+func readPowerSupply(dir string) PowerSupply {
+	p := PowerSupply{
+		Name:      filepath.Base(dir),
+		Type:      readString(dir, "type"),
+		Scope:     readString(dir, "scope"),
+		ModelName: readString(dir, "model_name"),
+		Status:    readString(dir, "status"),
+	}
+	p.Online = readString(dir, "online") == "1"
+	p.Capacity, p.hasCapacity = readNumber(dir, "capacity")
+	p.EnergyNow, p.hasEnergy = readMicro(dir, "energy_now")
+	p.EnergyFull, _ = readMicro(dir, "energy_full")
+	p.EnergyFullDesign, _ = readMicro(dir, "energy_full_design")
+	p.PowerNow, _ = readMicro(dir, "power_now")
+	if !p.hasEnergy {
+		// Some batteries report charge (µAh) and current (µA) instead
+		voltage, ok := readMicro(dir, "voltage_min_design")
+		if !ok {
+			voltage, _ = readMicro(dir, "voltage_now")
+		}
+		if chargeNow, ok := readMicro(dir, "charge_now"); ok {
+			p.hasEnergy = true
+			p.EnergyNow = chargeNow * voltage
+			chargeFull, _ := readMicro(dir, "charge_full")
+			p.EnergyFull = chargeFull * voltage
+			chargeFullDesign, _ := readMicro(dir, "charge_full_design")
+			p.EnergyFullDesign = chargeFullDesign * voltage
+		}
+		if current, ok := readMicro(dir, "current_now"); ok {
+			p.PowerNow = current * voltage
+		}
+	}
+	if p.PowerNow < 0 {
+		p.PowerNow = -p.PowerNow
+	}
+	if !p.hasCapacity && p.EnergyFull > 0 {
+		p.Capacity = p.EnergyNow / p.EnergyFull * 100
+	}
+	return p
+}
+
+// Reads all power supplies under `root`, sorted by name
+func ReadPowerSupplies(root string) ([]PowerSupply, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]PowerSupply, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, readPowerSupply(filepath.Join(root, e.Name())))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
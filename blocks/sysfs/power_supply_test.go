@@ -0,0 +1,62 @@
+package sysfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFakeSupply(t *testing.T, root, name string, files map[string]string) {
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range files {
+		if err := os.WriteFile(filepath.Join(dir, k), []byte(v+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadPowerSupplies(t *testing.T) {
+	root := t.TempDir()
+	writeFakeSupply(t, root, "AC", map[string]string{
+		"type":   "Mains",
+		"online": "1",
+	})
+	writeFakeSupply(t, root, "BAT0", map[string]string{
+		"type":               "Battery",
+		"status":             "Charging",
+		"capacity":           "50",
+		"energy_now":         "25000000",
+		"energy_full":        "50000000",
+		"energy_full_design": "56000000",
+		"power_now":          "12500000",
+	})
+	writeFakeSupply(t, root, "BAT1", map[string]string{
+		"type":               "Battery",
+		"status":             "Discharging",
+		"voltage_min_design": "10000000",
+		"charge_now":         "1000000",
+		"charge_full":        "4000000",
+		"current_now":        "500000",
+	})
+	supplies, err := ReadPowerSupplies(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(supplies) != 3 {
+		t.Fatalf("Expected 3 power supplies, got %d", len(supplies))
+	}
+	ac, bat0, bat1 := supplies[0], supplies[1], supplies[2]
+	if ac.Type != PowerSupplyTypeMains || !ac.Online {
+		t.Errorf("Unexpected AC: %+v", ac)
+	}
+	if bat0.Capacity != 50 || bat0.EnergyNow != 25 || bat0.PowerNow != 12.5 {
+		t.Errorf("Unexpected BAT0: %+v", bat0)
+	}
+	if bat1.EnergyNow != 10 || bat1.EnergyFull != 40 || bat1.Capacity != 25 ||
+		bat1.PowerNow != 5 {
+		t.Errorf("Unexpected BAT1: %+v", bat1)
+	}
+}
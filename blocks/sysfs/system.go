@@ -0,0 +1,75 @@
+package sysfs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	CPURoot   = "/sys/devices/system/cpu"
+	BlockRoot = "/sys/block"
+)
+
+// Reads current frequencies of CPU cores in Hz, ordered by core number.
+// Cores without cpufreq support are skipped
+func ReadCPUFrequencies(root string) ([]float64, error) {
+	dirs, err := filepath.Glob(filepath.Join(root, "cpu[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	coreNumber := func(dir string) int {
+		n, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "cpu"))
+		return n
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return coreNumber(dirs[i]) < coreNumber(dirs[j])
+	})
+	result := make([]float64, 0, len(dirs))
+	for _, dir := range dirs {
+		// In kHz
+		if f, ok := readNumber(filepath.Join(dir, "cpufreq"), "scaling_cur_freq"); ok {
+			result = append(result, f*1000)
+		}
+	}
+	return result, nil
+}
+
+// Memory statistics of a zram device, in bytes
+type Zram struct {
+	Name string
+	// Uncompressed size of the stored data
+	OrigDataSize uint64
+	// Compressed size of the stored data
+	ComprDataSize uint64
+	// Memory allocated for the device, including fragmentation and metadata
+	MemUsedTotal uint64
+}
+
+// Reads all zram devices under `root`, which is usually `BlockRoot`
+func ReadZram(root string) ([]Zram, error) {
+	dirs, err := filepath.Glob(filepath.Join(root, "zram*"))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Zram, 0, len(dirs))
+	for _, dir := range dirs {
+		b, err := os.ReadFile(filepath.Join(dir, "mm_stat"))
+		if err != nil {
+			// The device isn't initialized
+			continue
+		}
+		fields := strings.Fields(string(b))
+		if len(fields) < 3 {
+			continue
+		}
+		z := Zram{Name: filepath.Base(dir)}
+		z.OrigDataSize, _ = strconv.ParseUint(fields[0], 10, 64)
+		z.ComprDataSize, _ = strconv.ParseUint(fields[1], 10, 64)
+		z.MemUsedTotal, _ = strconv.ParseUint(fields[2], 10, 64)
+		result = append(result, z)
+	}
+	return result, nil
+}
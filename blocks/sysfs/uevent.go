@@ -0,0 +1,58 @@
+package sysfs
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"syscall"
+)
+
+// Listens for kernel uevents of the given subsystem (i.e. `power_supply`)
+// via netlink. The channel receives a value per matching uevent and is
+// closed when the context is done or the socket fails
+func ListenUevents(ctx context.Context, subsystem string) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(
+		syscall.AF_NETLINK,
+		syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK,
+		syscall.NETLINK_KOBJECT_UEVENT,
+	)
+	if err != nil {
+		return nil, err
+	}
+	addr := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: 1,
+	}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	// Wrapping a non-blocking fd lets the runtime poller interrupt Read
+	// when the file is closed
+	f := os.NewFile(uintptr(fd), "uevent")
+	needle := []byte("SUBSYSTEM=" + subsystem + "\x00")
+	ch := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+	go func() {
+		defer close(ch)
+		buf := make([]byte, 8192)
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+			if !bytes.Contains(buf[:n], needle) {
+				continue
+			}
+			select {
+			case ch <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -46,3 +46,10 @@ type I3barBlockletLogger interface {
 	I3barBlocklet
 	GetLogger() *log.Logger
 }
+
+// Marquee placeholders of such blocklets scroll only while the blocklet is
+// active, i.e. while media is playing
+type I3barBlockletActivity interface {
+	I3barBlocklet
+	IsActive() bool
+}
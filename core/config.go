@@ -17,6 +17,7 @@ type AppConfig struct {
 	Blocks         []BlockletConfig `yaml:"blocks"`
 	Theme          *ThemeConfig     `yaml:"theme"`
 	WatchConfig    *bool            `yaml:"watch_config"`
+	Marquee        MarqueeConfig    `yaml:"marquee"`
 }
 
 func LoadConfigFromFile(filename string) (*AppConfig, error) {
@@ -88,10 +89,19 @@ type BlockletConfig struct {
 // TODO: use different formatters?
 type ConfigFormat struct {
 	formatting.RustLikeFmt
+	// Set by the blocklet manager, if the blocklet config has the format
+	marquee *Marquee
 }
 
 func NewConfigFormatFromString(s string) *ConfigFormat {
-	return &ConfigFormat{formatting.NewFromString(s)}
+	return &ConfigFormat{RustLikeFmt: formatting.NewFromString(s)}
+}
+
+func (f *ConfigFormat) Expand(args formatting.NamedArgs) string {
+	if f.marquee == nil {
+		return f.RustLikeFmt.Expand(args)
+	}
+	return f.marquee.expand(f.RustLikeFmt, args)
 }
 
 type BaseBlockletConfig struct {
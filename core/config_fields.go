@@ -117,3 +117,24 @@ func (c *ConfigColor) String() string {
 	}
 	return s
 }
+
+// Maps mouse buttons (`left`, `middle`, `right`, `scroll_up`, `scroll_down`)
+// to blocklet-specific action names
+type ConfigButtonActions map[string]string
+
+func (a ConfigButtonActions) Get(b eventButton) string {
+	var key string
+	switch b {
+	case ButtonLeft:
+		key = "left"
+	case ButtonMiddle:
+		key = "middle"
+	case ButtonRight:
+		key = "right"
+	case ButtonScrollUp:
+		key = "scroll_up"
+	case ButtonScrollDown:
+		key = "scroll_down"
+	}
+	return a[key]
+}
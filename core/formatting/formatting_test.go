@@ -17,3 +17,54 @@ func RustLikeFormatting1(t *testing.T) {
 		t.Errorf(`Expected "%s" to be "%s"\n`, res, exp)
 	}
 }
+
+func TestMarquee(t *testing.T) {
+	f := RustLikeFmt(Parse("[{title^6~}] {artist^4}"))
+	args := NamedArgs{"title": "日本語テキスト", "artist": "Someone"}
+	res, scrolling := f.ExpandScrolled(args, 0)
+	if exp := "[日本語] Some"; res != exp || !scrolling {
+		t.Errorf(`Expected "%s" to be "%s" and scrolling`, res, exp)
+	}
+	res, _ = f.ExpandScrolled(args, 1)
+	if exp := "[本語テ] Some"; res != exp {
+		t.Errorf(`Expected "%s" to be "%s"`, res, exp)
+	}
+	// Wraps around with a gap; a wide character doesn't fit the last cell
+	res, _ = f.ExpandScrolled(args, 6)
+	if exp := "[ト    ] Some"; res != exp {
+		t.Errorf(`Expected "%s" to be "%s"`, res, exp)
+	}
+	if _, scrolling = f.ExpandScrolled(NamedArgs{"title": "short"}, 3); scrolling {
+		t.Errorf("Expected short title not to scroll")
+	}
+}
+
+func TestMinPrefix(t *testing.T) {
+	cases := []struct {
+		format string
+		value  interface{}
+		exp    string
+	}{
+		{"{rx;K*B/s}", 1500, "1.5KB/s"},
+		{"{rx;K*B/s}", 200, "0.2KB/s"},
+		{"{rx;K*B/s}", uint64(123456), "123KB/s"},
+		{"{rx;K*B/s}", 2.5e6, "2.5MB/s"},
+		{"{rx:3;K*B/s}", 20000, " 20KB/s"},
+		{"{v; 1*W}", 12.34, "12 W"},
+		{"{v;_K}", 2000, "2.0"},
+		{"{v;m*_V}", 0.0042, "4.2m"},
+		{"{v;1*%}", 0, "0.0%"},
+		{"{v;1*%}", uint8(42), "42%"},
+		{"{rx;K*B/s}", int16(1500), "1.5KB/s"},
+		{"{rx;K*B/s#100}", 2.5e6, "2.5MB/s"},
+	}
+	for _, c := range cases {
+		res := RustLikeFmt(Parse(c.format)).Expand(NamedArgs{
+			"rx": c.value,
+			"v":  c.value,
+		})
+		if res != c.exp {
+			t.Errorf(`Expected %s with %v to be "%s", got "%s"`, c.format, c.value, c.exp, res)
+		}
+	}
+}
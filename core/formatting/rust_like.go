@@ -1,6 +1,7 @@
 package formatting
 
 import (
+	"math"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -10,22 +11,23 @@ import (
 )
 
 var rustFmtRe = regexp.MustCompile(
-	`\{(\w+)(?::(0)?(\d+))?(?:\^(\d+))?(?:;( )?(_)?([num1KMGT]))?(?:\*(_)?([\w%]+))?(?:#(\d+))?(?:\$(\d*))?\}`,
+	`\{(\w+)(?::(0)?(\d+))?(?:\^(\d+)(~)?)?(?:;( )?(_)?([num1KMGT]))?(?:\*(_)?([\w%/]+))?(?:#(\d+))?(?:\$(\d*))?\}`,
 )
 
 // 2  3  name
 // 4  5  min width zero
 // 6  7  min width
 // 8  9  max width
-// 10 11 min prefix space
-// 12 13 min prefix underscore
-// 14 15 min prefix
-// 16 17 unit underscore
-// 18 19 unit
-// 20 21 bar max value
-// 22 23 trailing space count
-
-// {<name>[:[0]<min width>][^<max width>][;[ ][_]<min prefix>][*[_]<unit>][#<bar max value>]}
+// 10 11 marquee
+// 12 13 min prefix space
+// 14 15 min prefix underscore
+// 16 17 min prefix
+// 18 19 unit underscore
+// 20 21 unit
+// 22 23 bar max value
+// 24 25 trailing space count
+
+// {<name>[:[0]<min width>][^<max width>[~]][;[ ][_]<min prefix>][*[_]<unit>][#<bar max value>]}
 // (?:^|[^{])\{(\w+)(?::(\d+))?(?:\^(\d+))?\}
 
 type RustLikeFmt []fmtPart
@@ -39,7 +41,10 @@ type fmtPlaceholder struct {
 	name               string
 	minWidthZero       bool
 	minWidth, maxWidth int
-	// Engineering suffix, i.e. 1.0m, 4.3K etc
+	// Scroll the value instead of cutting it to max width
+	marquee bool
+	// Engineering suffix, i.e. 1.0m, 4.3K etc. Empty if the value is shown
+	// as is
 	minPrefix                     string
 	hideMinPrefix, minPrefixSpace bool
 	unit                          string
@@ -63,22 +68,79 @@ func (f *RustLikeFmt) UnmarshalYAML(node *yaml.Node) error {
 }
 
 func (f RustLikeFmt) Expand(args NamedArgs) string {
+	s, _ := f.ExpandScrolled(args, 0)
+	return s
+}
+
+// Gap between the end and the beginning of scrolling text
+const marqueeGap = "   "
+
+// Expands the format with marquee placeholders (`{name^N~}`) scrolled by
+// `step` characters. Also reports whether any of them is longer than its
+// max width, i.e. actually scrolls
+func (f RustLikeFmt) ExpandScrolled(args NamedArgs, step int) (string, bool) {
 	b := strings.Builder{}
+	scrolling := false
 	for _, part := range f {
 		if p := part.Placeholder; p != nil {
 			if value, ok := args[p.name]; ok {
-				b.WriteString(p.format(value))
+				s, overflows := p.format(value, step)
+				b.WriteString(s)
+				scrolling = scrolling || overflows
 			}
 		} else {
 			b.WriteString(part.Raw)
 		}
 	}
-	return b.String()
+	return b.String(), scrolling
+}
+
+type engineeringPrefix struct {
+	name, symbol string
+	multiplier   float64
 }
 
-func (p *fmtPlaceholder) format(value interface{}) string {
+var engineeringPrefixes = []engineeringPrefix{
+	{"n", "n", 1e-9},
+	{"u", "µ", 1e-6},
+	{"m", "m", 1e-3},
+	{"1", "", 1},
+	{"K", "K", 1e3},
+	{"M", "M", 1e6},
+	{"G", "G", 1e9},
+	{"T", "T", 1e12},
+}
+
+// Scales the number to the largest prefix which keeps it above 1, but not
+// to a smaller prefix than `minPrefix`. Returns the number and the prefix
+func formatEngineering(n float64, minPrefix string) (string, string) {
+	i := 0
+	for j, pr := range engineeringPrefixes {
+		if pr.name == minPrefix {
+			i = j
+			break
+		}
+	}
+	for i+1 < len(engineeringPrefixes) &&
+		math.Abs(n) >= engineeringPrefixes[i+1].multiplier {
+		i++
+	}
+	pr := engineeringPrefixes[i]
+	scaled := n / pr.multiplier
+	if math.Abs(scaled) < 10 {
+		return strconv.FormatFloat(scaled, 'f', 1, 64), pr.symbol
+	}
+	return strconv.FormatFloat(scaled, 'f', 0, 64), pr.symbol
+}
+
+func (p *fmtPlaceholder) format(value interface{}, step int) (string, bool) {
 	var r string
 	vof := reflect.ValueOf(value)
+	if p.minPrefix != "" {
+		if n, ok := toFloat(vof); ok {
+			return p.formatEngineering(n), false
+		}
+	}
 	switch vof.Kind() {
 	case reflect.Float32, reflect.Float64:
 		n := vof.Float()
@@ -87,13 +149,13 @@ func (p *fmtPlaceholder) format(value interface{}) string {
 		}
 		const floatPrecision = 8
 		r = strconv.FormatFloat(n, 'f', floatPrecision, 64)
-	case reflect.Int, reflect.Int32, reflect.Int64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		n := vof.Int()
 		if max := int64(p.barMaxValue); p.barMaxValue != -1 && n > max {
 			n = max
 		}
 		r = strconv.FormatInt(n, 10)
-	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		n := vof.Uint()
 		if max := uint64(p.barMaxValue); p.barMaxValue != -1 && n > max {
 			n = max
@@ -102,24 +164,64 @@ func (p *fmtPlaceholder) format(value interface{}) string {
 	case reflect.String:
 		r = vof.String()
 	}
-	suffix := ""
-	if p.unit == "%" {
-		suffix += "%"
+	r = p.pad(r)
+	overflows := false
+	if p.maxWidth > -1 && DisplayWidth(r) > p.maxWidth {
+		if p.marquee {
+			overflows = true
+			r = ScrollWindow(r, p.maxWidth, step, marqueeGap)
+		} else {
+			r = Truncate(r, p.maxWidth)
+		}
 	}
-	if p.trailingSpaceCount > 0 {
-		suffix += strings.Repeat(" ", p.trailingSpaceCount)
+	return r + p.suffix(""), overflows
+}
+
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
 	}
-	if p.minWidth > -1 && len(r) < p.minWidth {
+	return 0, false
+}
+
+func (p *fmtPlaceholder) formatEngineering(n float64) string {
+	r, prefix := formatEngineering(n, p.minPrefix)
+	if p.hideMinPrefix {
+		prefix = ""
+	}
+	return p.pad(r) + p.suffix(prefix)
+}
+
+// Pads the value to the min width
+func (p *fmtPlaceholder) pad(r string) string {
+	if w := DisplayWidth(r); p.minWidth > -1 && w < p.minWidth {
 		fill := " "
 		if p.minWidthZero {
 			fill = "0"
 		}
-		r = strings.Repeat(fill, p.minWidth-len(r)) + r
+		r = strings.Repeat(fill, p.minWidth-w) + r
+	}
+	return r
+}
+
+// Engineering prefix, unit and trailing spaces which follow the value
+func (p *fmtPlaceholder) suffix(prefix string) string {
+	suffix := prefix
+	if !p.hideUnit {
+		suffix += p.unit
+	}
+	if p.minPrefixSpace && suffix != "" {
+		suffix = " " + suffix
 	}
-	if p.maxWidth > -1 && len(r) > p.maxWidth {
-		r = r[:p.maxWidth]
+	if p.trailingSpaceCount > 0 {
+		suffix += strings.Repeat(" ", p.trailingSpaceCount)
 	}
-	return r + suffix
+	return suffix
 }
 
 func Parse(fstr string) (parts []fmtPart) {
@@ -147,33 +249,34 @@ func Parse(fstr string) (parts []fmtPart) {
 		} else {
 			maxWidth, _ = strconv.Atoi(fstr[m[8]:m[9]])
 		}
-		minPrefix := "1"
-		if m[14] != -1 {
-			minPrefix = fstr[m[14]:m[15]]
+		marquee := m[10] != -1
+		minPrefix := ""
+		if m[16] != -1 {
+			minPrefix = fstr[m[16]:m[17]]
 		}
 		hideMinPrefix, minPrefixSpace := false, false
-		if m[12] != -1 {
+		if m[14] != -1 {
 			hideMinPrefix = true
 		}
-		if m[10] != -1 {
+		if m[12] != -1 {
 			minPrefixSpace = true
 		}
 		unit := ""
-		if m[18] != -1 {
-			unit = fstr[m[18]:m[19]]
+		if m[20] != -1 {
+			unit = fstr[m[20]:m[21]]
 		}
 		hideUnit := false
-		if m[16] != -1 {
+		if m[18] != -1 {
 			hideUnit = true
 		}
 		barMaxValue := -1
-		if m[20] != -1 {
-			barMaxValue, _ = strconv.Atoi(fstr[m[20]:m[21]])
+		if m[22] != -1 {
+			barMaxValue, _ = strconv.Atoi(fstr[m[22]:m[23]])
 		}
 		trailingSpace := 0
-		if m[22] != -1 {
+		if m[24] != -1 {
 			trailingSpace = 1
-			if s := fstr[m[22]:m[23]]; s != "" {
+			if s := fstr[m[24]:m[25]]; s != "" {
 				trailingSpace, _ = strconv.Atoi(s)
 			}
 		}
@@ -181,6 +284,7 @@ func Parse(fstr string) (parts []fmtPart) {
 			name,
 			minWidthZero,
 			minWidth, maxWidth,
+			marquee,
 			minPrefix,
 			hideMinPrefix, minPrefixSpace,
 			unit,
@@ -0,0 +1,126 @@
+package formatting
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Ranges of characters which take two cells in a terminal-like font: East
+// Asian Wide and Fullwidth characters and emoji
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x23e9, 0x23ec},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f5},
+	{0x26fa, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x2753, 0x2755},
+	{0x2795, 0x2797},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x16fe0, 0x18cff},
+	{0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x3fffd},
+}
+
+// Returns the number of cells the rune takes: 0 for combining marks and
+// other invisible characters, 2 for wide characters and 1 otherwise
+func RuneWidth(r rune) int {
+	if r == 0x200d || (r >= 0xfe00 && r <= 0xfe0f) ||
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	if r < wideRanges[0][0] {
+		return 1
+	}
+	for _, rng := range wideRanges {
+		if r < rng[0] {
+			break
+		}
+		if r <= rng[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+func DisplayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += RuneWidth(r)
+	}
+	return w
+}
+
+// Cuts the string to at most `width` cells
+func Truncate(s string, width int) string {
+	w := 0
+	for i, r := range s {
+		rw := RuneWidth(r)
+		if w+rw > width {
+			return s[:i]
+		}
+		w += rw
+	}
+	return s
+}
+
+// Returns a `width` cells wide window of the text, scrolled by `step` runes.
+// The text wraps around with `gap` between the end and the beginning
+func ScrollWindow(s string, width, step int, gap string) string {
+	runes := []rune(s + gap)
+	if len(runes) == 0 {
+		return ""
+	}
+	step %= len(runes)
+	b := strings.Builder{}
+	w := 0
+	for i := 0; w < width && i < len(runes); i++ {
+		r := runes[(step+i)%len(runes)]
+		rw := RuneWidth(r)
+		if w+rw > width {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	// A wide character may not fit into the last cell
+	if w < width {
+		b.WriteString(strings.Repeat(" ", width-w))
+	}
+	return b.String()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"reflect"
 	"strings"
 	"sync"
 )
@@ -16,6 +17,8 @@ type BlockletMgr struct {
 	renderCache []I3barBlock
 	appConfig   *AppConfig
 	isError     bool
+	marquee     *Marquee
+	updateChan  UpdateChan
 }
 
 func MakeBlockletMgr(
@@ -25,7 +28,11 @@ func MakeBlockletMgr(
 ) BlockletMgr {
 	bmName := fmt.Sprintf("%s:%d", name, blockletCounters[name])
 	blockletCounters[name]++
-	return BlockletMgr{name: bmName, blocklet: b, appConfig: cfg}
+	m := &Marquee{}
+	if bc, ok := b.(I3barBlockletConfigurable); ok {
+		attachMarquee(reflect.ValueOf(bc.GetConfig()), m)
+	}
+	return BlockletMgr{name: bmName, blocklet: b, appConfig: cfg, marquee: m}
 }
 
 func (bm *BlockletMgr) invalidateCache() {
@@ -42,7 +49,9 @@ func (bm *BlockletMgr) invalidateCache() {
 		}
 		return
 	}
+	bm.marquee.beginRender()
 	blocks := bm.blocklet.Render(bm.appConfig)
+	bm.marquee.endRender()
 	for i := range blocks {
 		b := &blocks[i]
 		if b.Name == "" {
@@ -76,10 +85,15 @@ func (bm *BlockletMgr) initLogger() {
 	}
 }
 
-func (bm *BlockletMgr) Run(ch chan string, ctx context.Context, wg *sync.WaitGroup) {
+// Must be called before the blocklet runs and receives events
+func (bm *BlockletMgr) SetUpdateChan(ch chan string) {
+	bm.updateChan = UpdateChan{ch, bm.name}
+}
+
+func (bm *BlockletMgr) Run(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 	bm.initLogger()
-	uc := UpdateChan{ch, bm.name}
+	uc := bm.updateChan
 	defer func() {
 		if r := recover(); r != nil {
 			Log.Printf("error in blocklet %s:", bm.name)
@@ -118,6 +132,11 @@ func (bm *BlockletMgr) ProcessEvent(
 	wg *sync.WaitGroup,
 ) bool {
 	defer wg.Done()
+	pauseButton := e.Button == ButtonLeft || e.Button == ButtonMiddle
+	if pauseButton && !bm.isButtonBound(e.Button) && bm.pausesOnClick() &&
+		bm.marquee.togglePause() && bm.updateChan.ch != nil {
+		bm.updateChan.SendUpdate()
+	}
 	if cfg := bm.getBaseConfig(); cfg != nil {
 		if cfg.OnClick != nil {
 			cmd := e.ShellCommand(*cfg.OnClick, ctx)
@@ -133,6 +152,72 @@ func (bm *BlockletMgr) ProcessEvent(
 	return false
 }
 
+var buttonActionsType = reflect.TypeOf(ConfigButtonActions{})
+
+// Looks up the `actions` field in blocklet config
+func findButtonActions(v reflect.Value) ConfigButtonActions {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if !f.CanInterface() {
+			continue
+		}
+		if f.Type() == buttonActionsType {
+			return f.Interface().(ConfigButtonActions)
+		}
+		if v.Type().Field(i).Anonymous {
+			if a := findButtonActions(f); a != nil {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
+// Whether the click does something besides pausing the marquee: runs
+// `on_click` or an action bound to the button
+func (bm *BlockletMgr) isButtonBound(b eventButton) bool {
+	if cfg := bm.getBaseConfig(); cfg != nil && cfg.OnClick != nil {
+		return true
+	}
+	if bc, ok := bm.blocklet.(I3barBlockletConfigurable); ok {
+		return findButtonActions(reflect.ValueOf(bc.GetConfig())).Get(b) != ""
+	}
+	return false
+}
+
+func (bm *BlockletMgr) pausesOnClick() bool {
+	if bm.appConfig == nil || bm.appConfig.Marquee.PauseOnClick == nil {
+		return true
+	}
+	return *bm.appConfig.Marquee.PauseOnClick
+}
+
+// Whether the blocklet has marquee placeholders which should scroll now
+func (bm *BlockletMgr) IsScrolling() bool {
+	if !bm.marquee.isScrolling() {
+		return false
+	}
+	if b, ok := bm.blocklet.(I3barBlockletActivity); ok {
+		return b.IsActive()
+	}
+	return true
+}
+
+// Scrolls marquee placeholders by one character and re-renders blocklets
+func (bm *BlockletMgr) ScrollMarquee() {
+	bm.marquee.advance()
+	bm.invalidateCache()
+}
+
 // If name matches blocklet manager name, re-render blocklets
 func (bm *BlockletMgr) TryInvalidate(name string) {
 	if name == bm.name {
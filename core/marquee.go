@@ -0,0 +1,105 @@
+package core
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/kraftwerk28/gost/core/formatting"
+)
+
+const defaultMarqueeInterval = 300 * time.Millisecond
+
+type MarqueeConfig struct {
+	// How often marquee placeholders scroll by one character
+	Interval *ConfigInterval `yaml:"interval"`
+	// Left or middle click on a block pauses (or resumes) its scrolling,
+	// unless the blocklet has `on_click` or an action bound to the button
+	PauseOnClick *bool `yaml:"pause_on_click"`
+}
+
+func (c *AppConfig) MarqueeInterval() time.Duration {
+	if c == nil || c.Marquee.Interval == nil {
+		return defaultMarqueeInterval
+	}
+	return time.Duration(*c.Marquee.Interval)
+}
+
+// Scroll state of marquee placeholders (`{name^N~}`) of a blocklet
+type Marquee struct {
+	mu     sync.Mutex
+	step   int
+	paused bool
+	// Whether any placeholder overflowed during the last render
+	scrolling bool
+}
+
+func (m *Marquee) expand(f formatting.RustLikeFmt, args formatting.NamedArgs) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, scrolling := f.ExpandScrolled(args, m.step)
+	m.scrolling = m.scrolling || scrolling
+	return s
+}
+
+func (m *Marquee) beginRender() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scrolling = false
+}
+
+func (m *Marquee) endRender() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Start from the beginning when the text fits again
+	if !m.scrolling {
+		m.step = 0
+	}
+}
+
+func (m *Marquee) isScrolling() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.scrolling && !m.paused
+}
+
+func (m *Marquee) advance() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.step++
+}
+
+// Returns true if the pause state has changed
+func (m *Marquee) togglePause() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.scrolling {
+		return false
+	}
+	m.paused = !m.paused
+	return true
+}
+
+var configFormatType = reflect.TypeOf(ConfigFormat{})
+
+// Makes formats in blocklet config use the marquee. Formats inside slices and
+// maps, i.e. of notification rules, aren't rendered on the bar and are skipped
+func attachMarquee(v reflect.Value, m *Marquee) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Type().Elem() == configFormatType {
+			v.Interface().(*ConfigFormat).marquee = m
+			return
+		}
+		attachMarquee(v.Elem(), m)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanInterface() {
+				attachMarquee(f, m)
+			}
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package core
 
 import (
+	"fmt"
+	"math"
 	"strings"
+	"time"
 )
 
 func CountryFlagFromIsoCode(countryCode string) string {
@@ -50,3 +53,70 @@ func PercentageToHue(p int) int {
 // 	}()
 // 	return
 // }
+
+// Formats duration like `2h13m`. Seconds are shown only for durations
+// shorter than a minute
+func HumanDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh%02dm", h, m)
+}
+
+var progressBarParts = []rune(" ▏▎▍▌▋▊▉█")
+
+// Draws a bar `width` characters wide, filled according to `ratio` in [0, 1],
+// using eighth blocks for the partially filled character
+func ProgressBar(ratio float64, width int) string {
+	if ratio < 0 {
+		ratio = 0
+	} else if ratio > 1 {
+		ratio = 1
+	}
+	full := len(progressBarParts) - 1
+	eighths := int(math.Round(ratio * float64(width*full)))
+	b := strings.Builder{}
+	for i := 0; i < width; i++ {
+		n := eighths - i*full
+		if n > full {
+			n = full
+		} else if n < 0 {
+			n = 0
+		}
+		b.WriteRune(progressBarParts[n])
+	}
+	return b.String()
+}
+
+var sparklineParts = []rune("▁▂▃▄▅▆▇█")
+
+// Draws a character per value, scaled to the largest of them. The lowest
+// bar means zero
+func Sparkline(values []float64) string {
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+	return ScaledSparkline(values, max)
+}
+
+// Same as Sparkline, but the highest bar means `max`
+func ScaledSparkline(values []float64, max float64) string {
+	b := strings.Builder{}
+	for _, v := range values {
+		n := 0
+		if max > 0 && v > 0 {
+			n = int(math.Round(math.Min(v/max, 1) * float64(len(sparklineParts)-1)))
+		}
+		b.WriteRune(sparklineParts[n])
+	}
+	return b.String()
+}
@@ -119,7 +119,10 @@ func main() {
 			fields = append(fields, blockletField{
 				Name:     fieldName,
 				DataType: dataType,
-				Doc:      strings.TrimSpace(f.Doc.Text()),
+				// Table cells can't span multiple lines
+				Doc: strings.ReplaceAll(
+					strings.TrimSpace(f.Doc.Text()), "\n", " ",
+				),
 			})
 		}
 		blocklets = append(blocklets, BlockletDoc{
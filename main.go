@@ -198,23 +198,44 @@ outerLoop:
 		wg.Add(len(managers))
 		updateChan := make(chan string)
 		for i := range managers {
-			go managers[i].Run(updateChan, ctx, &wg)
+			managers[i].SetUpdateChan(updateChan)
+		}
+		for i := range managers {
+			go managers[i].Run(ctx, &wg)
 		}
 		go processEvents(ctx, managers, &wg, eventChan)
+		// Shared by all blocklets, runs only while some of them scroll text
+		var marqueeTicker *time.Ticker
+		var marqueeTick <-chan time.Time
 	renderLoop:
 		for {
 			blocks := make([]core.I3barBlock, 0, len(managers))
+			scrolling := false
 			for i := range managers {
 				blocks = append(blocks, managers[i].Render()...)
+				scrolling = scrolling || managers[i].IsScrolling()
 			}
 			if err := feedBlocks(os.Stdout, blocks); err != nil {
 				log.Print(err)
 			}
+			if scrolling && marqueeTicker == nil {
+				marqueeTicker = time.NewTicker(cfg.MarqueeInterval())
+				marqueeTick = marqueeTicker.C
+			} else if !scrolling && marqueeTicker != nil {
+				marqueeTicker.Stop()
+				marqueeTicker, marqueeTick = nil, nil
+			}
 			select {
 			case updateData := <-updateChan:
 				for i := range managers {
 					managers[i].TryInvalidate(updateData)
 				}
+			case <-marqueeTick:
+				for i := range managers {
+					if managers[i].IsScrolling() {
+						managers[i].ScrollMarquee()
+					}
+				}
 			case signal := <-signalChan:
 				switch signal {
 				case syscall.SIGHUP:
@@ -244,6 +265,9 @@ outerLoop:
 				}
 			}
 		}
+		if marqueeTicker != nil {
+			marqueeTicker.Stop()
+		}
 	}
 	log.Println("Auf Wiedersehen")
 }
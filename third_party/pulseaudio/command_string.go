@@ -0,0 +1,16 @@
+// Code generated by "stringer -type=command"; DO NOT EDIT.
+
+package pulseaudio
+
+import "strconv"
+
+const _command_name = "commandErrorcommandTimeoutcommandReplycommandCreatePlaybackStreamcommandDeletePlaybackStreamcommandCreateRecordStreamcommandDeleteRecordStreamcommandExitcommandAuthcommandSetClientNamecommandLookupSinkcommandLookupSourcecommandDrainPlaybackStreamcommandStatcommandGetPlaybackLatencycommandCreateUploadStreamcommandDeleteUploadStreamcommandFinishUploadStreamcommandPlaySamplecommandRemoveSamplecommandGetServerInfocommandGetSinkInfocommandGetSinkInfoListcommandGetSourceInfocommandGetSourceInfoListcommandGetModuleInfocommandGetModuleInfoListcommandGetClientInfocommandGetClientInfoListcommandGetSinkInputInfocommandGetSinkInputInfoListcommandGetSourceOutputInfocommandGetSourceOutputInfoListcommandGetSampleInfocommandGetSampleInfoListcommandSubscribecommandSetSinkVolumecommandSetSinkInputVolumecommandSetSourceVolumecommandSetSinkMutecommandSetSourceMutecommandCorkPlaybackStreamcommandFlushPlaybackStreamcommandTriggerPlaybackStreamcommandSetDefaultSinkcommandSetDefaultSourcecommandSetPlaybackStreamNamecommandSetRecordStreamNamecommandKillClientcommandKillSinkInputcommandKillSourceOutputcommandLoadModulecommandUnloadModulecommandAddAutoloadObsoletecommandRemoveAutoloadObsoletecommandGetAutoloadInfoObsoletecommandGetAutoloadInfoListObsoletecommandGetRecordLatencycommandCorkRecordStreamcommandFlushRecordStreamcommandPrebufPlaybackStreamcommandRequestcommandOverflowcommandUnderflowcommandPlaybackStreamKilledcommandRecordStreamKilledcommandSubscribeEventcommandMoveSinkInputcommandMoveSourceOutputcommandSetSinkInputMutecommandSuspendSinkcommandSuspendSourcecommandSetPlaybackStreamBufferAttrcommandSetRecordStreamBufferAttrcommandUpdatePlaybackStreamSampleRatecommandUpdateRecordStreamSampleRatecommandPlaybackStreamSuspendedcommandRecordStreamSuspendedcommandPlaybackStreamMovedcommandRecordStreamMovedcommandUpdateRecordStreamProplistcommandUpdatePlaybackStreamProplistcommandUpdateClientProplistcommandRemoveRecordStreamProplistcommandRemovePlaybackStreamProplistcommandRemoveClientProplistcommandStartedcommandExtensioncommandGetCardInfocommandGetCardInfoListcommandSetCardProfilecommandClientEventcommandPlaybackStreamEventcommandRecordStreamEventcommandPlaybackBufferAttrChangedcommandRecordBufferAttrChangedcommandSetSinkPortcommandSetSourcePortcommandSetSourceOutputVolumecommandSetSourceOutputMutecommandSetPortLatencyOffsetcommandEnableSrbchannelcommandDisableSrbchannelcommandRegisterMemfdShmidcommandMax"
+
+var _command_index = [...]uint16{0, 12, 26, 38, 65, 92, 117, 142, 153, 164, 184, 201, 220, 246, 257, 282, 307, 332, 357, 374, 393, 413, 431, 453, 473, 497, 517, 541, 561, 585, 608, 635, 661, 691, 711, 735, 751, 771, 796, 818, 836, 856, 881, 907, 935, 956, 979, 1007, 1033, 1050, 1070, 1093, 1110, 1129, 1155, 1184, 1214, 1248, 1271, 1294, 1318, 1345, 1359, 1374, 1390, 1417, 1442, 1463, 1483, 1506, 1529, 1547, 1567, 1601, 1633, 1670, 1705, 1735, 1763, 1789, 1813, 1846, 1881, 1908, 1941, 1976, 2003, 2017, 2033, 2051, 2073, 2094, 2112, 2138, 2162, 2194, 2224, 2242, 2262, 2290, 2316, 2343, 2366, 2390, 2415, 2425}
+
+func (i command) String() string {
+	if i >= command(len(_command_index)-1) {
+		return "command(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _command_name[_command_index[i]:_command_index[i+1]]
+}
@@ -0,0 +1,31 @@
+package pulseaudio
+
+var errors = []string{
+	"OK",
+	"Access denied",
+	"Unknown command",
+	"Invalid argument",
+	"Entity exists",
+	"No such entity",
+	"Connection refused",
+	"Protocol error",
+	"Timeout",
+	"No authentication key",
+	"Internal error",
+	"Connection terminated",
+	"Entity killed",
+	"Invalid server",
+	"Module initialization failed",
+	"Bad state",
+	"No data",
+	"Incompatible protocol version",
+	"Too large",
+	"Not supported",
+	"Unknown error code",
+	"No such extension",
+	"Obsolete functionality",
+	"Missing implementation",
+	"Client forked",
+	"Input/Output error",
+	"Device or resource busy",
+}
@@ -0,0 +1,3 @@
+module github.com/kraftwerk28/pulseaudio
+
+go 1.14
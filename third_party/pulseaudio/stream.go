@@ -0,0 +1,129 @@
+package pulseaudio
+
+import "io"
+
+// SinkInput contains information about a playback stream in pulseaudio
+type SinkInput struct {
+	Index          uint32
+	Name           string
+	ModuleIndex    uint32
+	ClientIndex    uint32
+	SinkIndex      uint32
+	SampleSpec     sampleSpec
+	ChannelMap     channelMap
+	Cvolume        cvolume
+	BufferUsec     uint64
+	SinkUsec       uint64
+	ResampleMethod string
+	Driver         string
+	Muted          bool
+	PropList       map[string]string
+	Corked         bool
+	HasVolume      bool
+	VolumeWritable bool
+	Format         formatInfo
+}
+
+// ReadFrom deserializes a sink input packet from pulseaudio
+func (s *SinkInput) ReadFrom(r io.Reader) (int64, error) {
+	return 0, bread(r,
+		uint32Tag, &s.Index,
+		stringTag, &s.Name,
+		uint32Tag, &s.ModuleIndex,
+		uint32Tag, &s.ClientIndex,
+		uint32Tag, &s.SinkIndex,
+		&s.SampleSpec,
+		&s.ChannelMap,
+		&s.Cvolume,
+		usecTag, &s.BufferUsec,
+		usecTag, &s.SinkUsec,
+		stringTag, &s.ResampleMethod,
+		stringTag, &s.Driver,
+		&s.Muted,
+		&s.PropList,
+		&s.Corked,
+		&s.HasVolume,
+		&s.VolumeWritable,
+		&s.Format)
+}
+
+// SinkInputs queries PulseAudio for a list of playback streams
+func (c *Client) SinkInputs() ([]SinkInput, error) {
+	b, err := c.request(commandGetSinkInputInfoList)
+	if err != nil {
+		return nil, err
+	}
+	var inputs []SinkInput
+	for b.Len() > 0 {
+		var input SinkInput
+		err = bread(b, &input)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+// SourceOutput contains information about a recording stream in pulseaudio
+type SourceOutput struct {
+	Index          uint32
+	Name           string
+	ModuleIndex    uint32
+	ClientIndex    uint32
+	SourceIndex    uint32
+	SampleSpec     sampleSpec
+	ChannelMap     channelMap
+	BufferUsec     uint64
+	SourceUsec     uint64
+	ResampleMethod string
+	Driver         string
+	PropList       map[string]string
+	Corked         bool
+	Cvolume        cvolume
+	Muted          bool
+	HasVolume      bool
+	VolumeWritable bool
+	Format         formatInfo
+}
+
+// ReadFrom deserializes a source output packet from pulseaudio
+func (s *SourceOutput) ReadFrom(r io.Reader) (int64, error) {
+	return 0, bread(r,
+		uint32Tag, &s.Index,
+		stringTag, &s.Name,
+		uint32Tag, &s.ModuleIndex,
+		uint32Tag, &s.ClientIndex,
+		uint32Tag, &s.SourceIndex,
+		&s.SampleSpec,
+		&s.ChannelMap,
+		usecTag, &s.BufferUsec,
+		usecTag, &s.SourceUsec,
+		stringTag, &s.ResampleMethod,
+		stringTag, &s.Driver,
+		&s.PropList,
+		&s.Corked,
+		&s.Cvolume,
+		&s.Muted,
+		&s.HasVolume,
+		&s.VolumeWritable,
+		&s.Format)
+}
+
+// SourceOutputs queries PulseAudio for a list of recording streams
+func (c *Client) SourceOutputs() ([]SourceOutput, error) {
+	b, err := c.request(commandGetSourceOutputInfoList)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []SourceOutput
+	for b.Len() > 0 {
+		var output SourceOutput
+		err = bread(b, &output)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
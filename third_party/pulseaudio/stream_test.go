@@ -0,0 +1,83 @@
+package pulseaudio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSinkInputReadFrom(t *testing.T) {
+	var b bytes.Buffer
+	err := bwrite(&b,
+		uint32Tag, uint32(42),
+		stringTag, []byte("Playback"), byte(0),
+		uint32Tag, uint32(7),
+		uint32Tag, uint32(3),
+		uint32Tag, uint32(1),
+		sampleSpecTag, byte(3), byte(2), uint32(48000),
+		channelMapTag, byte(2), []byte{1, 2},
+		cvolume{0x8000, 0x10000},
+		usecTag, uint64(1000),
+		usecTag, uint64(2000),
+		stringNullTag,
+		stringTag, []byte("protocol-native.c"), byte(0),
+		falseTag,
+		map[string]string{"application.name": "Firefox"},
+		trueTag,
+		trueTag,
+		trueTag,
+		formatInfoTag, uint8Tag, byte(1), map[string]string{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s SinkInput
+	if err := bread(&b, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Index != 42 || s.Name != "Playback" || s.SinkIndex != 1 ||
+		len(s.Cvolume) != 2 || s.Cvolume[1] != 0x10000 || s.ResampleMethod != "" ||
+		s.PropList["application.name"] != "Firefox" || !s.Corked || s.Muted {
+		t.Errorf("Unexpected sink input: %+v", s)
+	}
+	if b.Len() != 0 {
+		t.Errorf("%d bytes left unread", b.Len())
+	}
+}
+
+func TestSourceOutputReadFrom(t *testing.T) {
+	var b bytes.Buffer
+	err := bwrite(&b,
+		uint32Tag, uint32(5),
+		stringTag, []byte("Recording"), byte(0),
+		uint32Tag, uint32(7),
+		uint32Tag, uint32(3),
+		uint32Tag, uint32(2),
+		sampleSpecTag, byte(3), byte(1), uint32(44100),
+		channelMapTag, byte(1), []byte{0},
+		usecTag, uint64(1000),
+		usecTag, uint64(2000),
+		stringNullTag,
+		stringTag, []byte("protocol-native.c"), byte(0),
+		map[string]string{"application.process.binary": "obs"},
+		falseTag,
+		cvolume{0x10000},
+		trueTag,
+		trueTag,
+		trueTag,
+		formatInfoTag, uint8Tag, byte(1), map[string]string{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s SourceOutput
+	if err := bread(&b, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Index != 5 || s.SourceIndex != 2 || s.Corked || !s.Muted ||
+		s.PropList["application.process.binary"] != "obs" {
+		t.Errorf("Unexpected source output: %+v", s)
+	}
+	if b.Len() != 0 {
+		t.Errorf("%d bytes left unread", b.Len())
+	}
+}